@@ -0,0 +1,135 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import "slices"
+
+// Number is a constraint for types that support addition and subtraction.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Aggregate is a [Generator] that wraps another generator and maintains an aggregate
+// value of its current result, like a sum or a hash. Instead of calculating the value
+// from the entire result on every call to [Aggregate.Next], it updates the value from
+// the elements reported by [DeltaGenerator.LastChange], which takes constant time for
+// most generators.
+type Aggregate[T, A any] struct {
+	gen         DeltaGenerator[T]
+	zero, value A
+	add, remove func(A, T) A
+	shadow      []T
+}
+
+// WithAggregate wraps the generator into an [Aggregate] with the given functions, where
+// zero is the value for an empty result, add adds an element to the value, and remove
+// removes an element from it, e.g. for a product, zero is 1, add multiplies and
+// remove divides. The value must not depend on the order of elements, and remove must
+// be the inverse of add.
+//
+// The wrapped generator can still be used directly, e.g. to seek to another position,
+// but it must be advanced only through the aggregate.
+func WithAggregate[T, A any](gen DeltaGenerator[T], zero A, add, remove func(acc A, v T) A) *Aggregate[T, A] {
+	return &Aggregate[T, A]{
+		gen:    gen,
+		zero:   zero,
+		value:  zero,
+		add:    add,
+		remove: remove,
+	}
+}
+
+// SumTracker wraps the generator into an [Aggregate] that maintains the sum of elements
+// of its current result. With floating point numbers, the sum can accumulate rounding
+// errors over time, so it's recalculated whenever the generator reports [ChangeAll].
+func SumTracker[T Number](gen DeltaGenerator[T]) *Aggregate[T, T] {
+	return WithAggregate(gen, 0,
+		func(acc, v T) T { return acc + v },
+		func(acc, v T) T { return acc - v },
+	)
+}
+
+// Value returns the aggregate value of the current result.
+func (a *Aggregate[T, A]) Value() A {
+	return a.value
+}
+
+// Generator returns the wrapped generator.
+func (a *Aggregate[T, A]) Generator() DeltaGenerator[T] {
+	return a.gen
+}
+
+// Next produces a new result in the wrapped generator and updates the aggregate value.
+// If it returns false, there are no more results available.
+func (a *Aggregate[T, A]) Next() bool {
+	if !a.gen.Next() {
+		return false
+	}
+
+	cur := a.gen.Current()
+	c := a.gen.LastChange()
+
+	switch c.Kind {
+	case ChangeReplace:
+		a.value = a.add(a.remove(a.value, a.shadow[c.I]), cur[c.I])
+		a.shadow[c.I] = cur[c.I]
+	case ChangeSwap:
+		a.shadow[c.I], a.shadow[c.J] = a.shadow[c.J], a.shadow[c.I]
+	case ChangeRotate:
+		e := a.shadow[c.J]
+		copy(a.shadow[c.I+1:c.J+1], a.shadow[c.I:c.J])
+		a.shadow[c.I] = e
+	case ChangeRange:
+		for i := c.I; i < c.J; i++ {
+			a.value = a.add(a.remove(a.value, a.shadow[i]), cur[i])
+			a.shadow[i] = cur[i]
+		}
+	case ChangeInsert:
+		a.value = a.add(a.value, cur[c.I])
+		a.shadow = slices.Insert(a.shadow, c.I, cur[c.I])
+	case ChangeRemove:
+		a.value = a.remove(a.value, a.shadow[c.I])
+		a.shadow = slices.Delete(a.shadow, c.I, c.I+1)
+	default:
+		a.value = a.zero
+
+		for _, v := range cur {
+			a.value = a.add(a.value, v)
+		}
+
+		a.shadow = append(a.shadow[:0], cur...)
+	}
+
+	return true
+}
+
+// Current returns the current result of the wrapped generator.
+func (a *Aggregate[T, A]) Current() []T {
+	return a.gen.Current()
+}
+
+// CurrentCopy returns a copy of the current result of the wrapped generator.
+func (a *Aggregate[T, A]) CurrentCopy() []T {
+	return a.gen.CurrentCopy()
+}
+
+// SetDest sets a destination slice of the wrapped generator.
+func (a *Aggregate[T, A]) SetDest(dest []T) error {
+	return a.gen.SetDest(dest)
+}
+
+// Reset resets the wrapped generator and the aggregate value.
+func (a *Aggregate[T, A]) Reset() {
+	a.gen.Reset()
+	a.value = a.zero
+	a.shadow = a.shadow[:0]
+}
+
+// LastChange returns the last change of the wrapped generator.
+func (a *Aggregate[T, A]) LastChange() Change {
+	return a.gen.LastChange()
+}
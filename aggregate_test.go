@@ -0,0 +1,148 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"testing"
+)
+
+func sum(s []int) int {
+	ret := 0
+
+	for _, v := range s {
+		ret += v
+	}
+
+	return ret
+}
+
+func TestSumTracker(t *testing.T) {
+	items := []int{1, 2, 4, 8, 16, 32}
+	reps := []int{2, 1, 3, 1}
+
+	gens := []struct {
+		name string
+		gen  func() (DeltaGenerator[int], error)
+	}{
+		{"Combination", func() (DeltaGenerator[int], error) { return NewCombinationGenerator(3, items) }},
+		{"Permutation", func() (DeltaGenerator[int], error) { return NewPermutationGenerator(items[:5]) }},
+		{"MultiPermutation", func() (DeltaGenerator[int], error) { return NewMultiPermutationGenerator(items[:4], reps) }},
+		{"Variation", func() (DeltaGenerator[int], error) { return NewVariationGenerator(3, items[:4]) }},
+		{"CombinationWithRepetition", func() (DeltaGenerator[int], error) { return NewCombinationWithRepetitionGenerator(3, items[:4]) }},
+		{"KPermutation", func() (DeltaGenerator[int], error) { return NewKPermutationGenerator(3, items[:5]) }},
+		{"MultiCombination", func() (DeltaGenerator[int], error) { return NewMultiCombinationGenerator(4, items[:4], reps) }},
+		{"Subset", func() (DeltaGenerator[int], error) { return NewSubsetGenerator(1, 4, items) }},
+		{"SubsetGray", func() (DeltaGenerator[int], error) { return NewSubsetGenerator(0, 6, items, Gray()) }},
+	}
+
+	for _, g := range gens {
+		g := g
+
+		t.Run(g.name, func(t *testing.T) {
+			gen, err := g.gen()
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			st := SumTracker(gen)
+
+			for i := 0; i < 2; i++ {
+				count := 0
+
+				for st.Next() {
+					if want := sum(st.Current()); st.Value() != want {
+						t.Fatalf("Wrong sum of %v, got: %v, want: %v", st.Current(), st.Value(), want)
+					}
+
+					count++
+				}
+
+				if count == 0 {
+					t.Errorf("No results")
+				}
+
+				st.Reset()
+
+				if st.Value() != 0 {
+					t.Errorf("Sum not reset, got: %v", st.Value())
+				}
+			}
+
+			// seeking the wrapped generator
+			st.Generator().(SeekableGenerator[int]).Seek(2)
+
+			if st.Next(); st.Value() != sum(st.Current()) {
+				t.Errorf("Wrong sum after seek, got: %v, want: %v", st.Value(), sum(st.Current()))
+			}
+		})
+	}
+}
+
+func TestWithAggregate(t *testing.T) {
+	items := []string{"A", "B", "C", "D", "E"}
+	gen, _ := NewPermutationGenerator(items)
+
+	// the total length of strings doesn't depend on their order
+	agg := WithAggregate(gen, 0,
+		func(acc int, v string) int { return acc + len(v) },
+		func(acc int, v string) int { return acc - len(v) },
+	)
+
+	for agg.Next() {
+		if agg.Value() != 5 {
+			t.Fatalf("Wrong value for %v, got: %v", agg.Current(), agg.Value())
+		}
+	}
+
+	xor := func(acc uint64, v int) uint64 { return acc ^ (uint64(v) * 0x9e3779b97f4a7c15) }
+	cgen, _ := NewCombinationGenerator(3, []int{1, 2, 3, 4, 5, 6})
+	hash := WithAggregate(cgen, 0, xor, xor)
+	seen := map[uint64]string{}
+
+	for hash.Next() {
+		want := uint64(0)
+
+		for _, v := range hash.Current() {
+			want = xor(want, v)
+		}
+
+		if hash.Value() != want {
+			t.Fatalf("Wrong hash of %v, got: %v, want: %v", hash.Current(), hash.Value(), want)
+		}
+
+		if s, ok := seen[want]; ok {
+			t.Errorf("Hash collision of %v and %v", s, hash.Current())
+		}
+
+		seen[want] = fmt.Sprint(hash.Current())
+	}
+}
+
+func BenchmarkSumTracker(b *testing.B) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	gen, _ := NewCombinationGenerator(5, items)
+
+	b.Run("tracker", func(b *testing.B) {
+		st := SumTracker[int](gen)
+
+		for i := 0; i < b.N; i++ {
+			st.Reset()
+			for st.Next() {
+				st.Value()
+			}
+		}
+	})
+
+	b.Run("sum", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			gen.Reset()
+			for gen.Next() {
+				sum(gen.Current())
+			}
+		}
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import "fmt"
+
+// ChangeKind is the kind of a change between two consecutive results of a generator.
+type ChangeKind int
+
+const (
+	// ChangeNone means that Next hasn't produced a result since the generator was
+	// initialized, reset or moved by Seek.
+	ChangeNone ChangeKind = iota
+
+	// ChangeAll means that the result has no relation to the previous one,
+	// e.g. it's the first result after initialization, reset or Seek.
+	ChangeAll
+
+	// ChangeReplace means that the element at position I was replaced. Old and New
+	// are positions of the old and the new element in the input slice.
+	ChangeReplace
+
+	// ChangeSwap means that elements at positions I and J were swapped.
+	ChangeSwap
+
+	// ChangeRotate means that elements at positions I to J (inclusive) were rotated
+	// by one position to the right, i.e. the element at J moved to I.
+	ChangeRotate
+
+	// ChangeRange means that any of the elements at positions I to J (exclusive)
+	// could have changed.
+	ChangeRange
+
+	// ChangeInsert means that an element was inserted at position I, moving the
+	// following elements to the right. New is the position of the element in the input slice.
+	ChangeInsert
+
+	// ChangeRemove means that the element at position I was removed, moving the
+	// following elements to the left. Old is the position of the element in the input slice.
+	ChangeRemove
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeNone:
+		return "None"
+	case ChangeAll:
+		return "All"
+	case ChangeReplace:
+		return "Replace"
+	case ChangeSwap:
+		return "Swap"
+	case ChangeRotate:
+		return "Rotate"
+	case ChangeRange:
+		return "Range"
+	case ChangeInsert:
+		return "Insert"
+	case ChangeRemove:
+		return "Remove"
+	}
+
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change describes how the result of a generator changed by the last call to Next.
+// Meaning of the fields depends on the Kind, unused fields are zero.
+type Change struct {
+	Kind     ChangeKind
+	I, J     int
+	Old, New int
+}
+
+// DeltaGenerator is a [Generator] that reports how its result changed by the last
+// call to Next, so that the state derived from the result can be updated instead of
+// recalculated. All generators in this package implement it.
+type DeltaGenerator[T any] interface {
+	Generator[T]
+	LastChange() Change
+}
+
+// Returns the change of positions from i to k, where old is the old position
+// of the element at i in the input slice, and new is the new one.
+func rangeChange(i, k, old, new int) Change {
+	if i == k-1 {
+		return Change{Kind: ChangeReplace, I: i, Old: old, New: new}
+	}
+
+	return Change{Kind: ChangeRange, I: i, J: k}
+}
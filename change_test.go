@@ -0,0 +1,124 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Applies the change to the previous result, checking that it matches the elements.
+func applyChange[T comparable](t *testing.T, prev, cur []T, c Change, elems []T) []T {
+	t.Helper()
+
+	switch c.Kind {
+	case ChangeAll:
+		return slices.Clone(cur)
+	case ChangeReplace:
+		if prev[c.I] != elems[c.Old] {
+			t.Errorf("Wrong old element in %v: %v", c, prev[c.I])
+		}
+		prev[c.I] = elems[c.New]
+	case ChangeSwap:
+		prev[c.I], prev[c.J] = prev[c.J], prev[c.I]
+	case ChangeRotate:
+		e := prev[c.J]
+		copy(prev[c.I+1:c.J+1], prev[c.I:c.J])
+		prev[c.I] = e
+	case ChangeRange:
+		copy(prev[c.I:c.J], cur[c.I:c.J])
+	case ChangeInsert:
+		prev = slices.Insert(prev, c.I, elems[c.New])
+	case ChangeRemove:
+		if prev[c.I] != elems[c.Old] {
+			t.Errorf("Wrong old element in %v: %v", c, prev[c.I])
+		}
+		prev = slices.Delete(prev, c.I, c.I+1)
+	default:
+		t.Errorf("Unexpected change: %v", c)
+	}
+
+	return prev
+}
+
+// Checks that applying every reported change to the previous result gives the current one.
+func testChanges[T comparable](t *testing.T, gen DeltaGenerator[T], elems []T) {
+	t.Helper()
+
+	if c := gen.LastChange(); c.Kind != ChangeNone {
+		t.Errorf("Want no change before Next, got %v", c)
+	}
+
+	var prev []T
+
+	for gen.Next() {
+		c := gen.LastChange()
+
+		if prev == nil && c.Kind != ChangeAll {
+			t.Errorf("Want change of all elements first, got %v", c)
+		}
+
+		prev = applyChange(t, prev, gen.Current(), c, elems)
+
+		if !slices.Equal(prev, gen.Current()) {
+			t.Fatalf("Not equal after %v, \ngot: %v, \nwant: %v", c, prev, gen.Current())
+		}
+	}
+
+	if sg, ok := gen.(SeekableGenerator[T]); ok {
+		sg.Seek(1)
+
+		if c := gen.LastChange(); c.Kind != ChangeNone {
+			t.Errorf("Want no change after Seek, got %v", c)
+		}
+		if gen.Next(); gen.LastChange().Kind != ChangeAll {
+			t.Errorf("Want change of all elements after Seek, got %v", gen.LastChange())
+		}
+	}
+}
+
+func TestLastChange(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4, 5}
+	reps := []int{2, 1, 3, 1}
+
+	gens := []struct {
+		name string
+		gen  func() (DeltaGenerator[int], error)
+	}{
+		{"Combination", func() (DeltaGenerator[int], error) { return NewCombinationGenerator(3, items) }},
+		{"Permutation", func() (DeltaGenerator[int], error) { return NewPermutationGenerator(items[:5]) }},
+		{"MultiPermutation", func() (DeltaGenerator[int], error) { return NewMultiPermutationGenerator(items[:4], reps) }},
+		{"Variation", func() (DeltaGenerator[int], error) { return NewVariationGenerator(3, items[:4]) }},
+		{"CombinationWithRepetition", func() (DeltaGenerator[int], error) { return NewCombinationWithRepetitionGenerator(3, items[:4]) }},
+		{"KPermutation", func() (DeltaGenerator[int], error) { return NewKPermutationGenerator(3, items[:5]) }},
+		{"MultiCombination", func() (DeltaGenerator[int], error) { return NewMultiCombinationGenerator(4, items[:4], reps) }},
+		{"Subset", func() (DeltaGenerator[int], error) { return NewSubsetGenerator(1, 4, items) }},
+		{"SubsetGray", func() (DeltaGenerator[int], error) { return NewSubsetGenerator(0, 6, items, Gray()) }},
+	}
+
+	for _, g := range gens {
+		g := g
+
+		t.Run(g.name, func(t *testing.T) {
+			gen, err := g.gen()
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			testChanges(t, gen, items)
+		})
+	}
+}
+
+func TestChangeKindString(t *testing.T) {
+	if s := fmt.Sprint(ChangeRotate); s != "Rotate" {
+		t.Errorf("Want Rotate, got %v", s)
+	}
+	if s := fmt.Sprint(ChangeKind(100)); s != "ChangeKind(100)" {
+		t.Errorf("Want ChangeKind(100), got %v", s)
+	}
+}
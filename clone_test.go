@@ -0,0 +1,121 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+type cloneableGenerator[T any, G any] interface {
+	SeekableGenerator[T]
+	Clone() G
+}
+
+// Checks that clones made at every position of the generator continue the same sequence
+// as the original, without affecting it.
+func testClone[T cmp.Ordered, G cloneableGenerator[T, G]](t *testing.T, gen G) {
+	t.Helper()
+
+	want := make([][]T, 0)
+
+	for gen.Next() {
+		want = append(want, gen.CurrentCopy())
+	}
+
+	gen.Reset()
+
+	for {
+		c := gen.Clone()
+		cur := gen.CurrentCopy()
+
+		if !slices.Equal(c.Current(), cur) || c.Position() != gen.Position() {
+			t.Fatalf("Clone differs at %v, \ngot: %v, \nwant: %v", gen.Position(), c.Current(), cur)
+		}
+
+		for c.Next() {
+			if slices.Compare(c.Current(), want[c.Position()]) != 0 {
+				t.Fatalf("Not equal at %v, \ngot: %v, \nwant: %v", c.Position(), c.Current(), want[c.Position()])
+			}
+		}
+
+		if c.Position() != len(want)-1 {
+			t.Errorf("Clone ended at %v, want: %v", c.Position(), len(want)-1)
+		}
+
+		if !slices.Equal(gen.Current(), cur) {
+			t.Fatalf("Original changed by clone at %v, \ngot: %v, \nwant: %v", gen.Position(), gen.Current(), cur)
+		}
+
+		if !gen.Next() {
+			break
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+
+	cgen, _ := NewCombinationGenerator(2, items)
+	testClone[int](t, cgen)
+
+	cigen, _ := NewCombinationIndexGenerator(2, 4)
+	testClone[int](t, cigen)
+
+	pgen, _ := NewPermutationGenerator(items)
+	testClone[int](t, pgen)
+
+	pigen, _ := NewPermutationIndexGenerator(4)
+	testClone[int](t, pigen)
+
+	mgen, _ := NewMultiPermutationGenerator(items[:3], []int{2, 1, 2})
+	testClone[int](t, mgen)
+
+	migen, _ := NewMultiPermutationIndexGenerator([]int{2, 1, 2})
+	testClone[int](t, migen)
+
+	vgen, _ := NewVariationGenerator(3, items[:3])
+	testClone[int](t, vgen)
+
+	vigen, _ := NewVariationIndexGenerator(2, 3)
+	testClone[int](t, vigen)
+
+	rgen, _ := NewCombinationWithRepetitionGenerator(3, items)
+	testClone[int](t, rgen)
+
+	kgen, _ := NewKPermutationGenerator(2, items)
+	testClone[int](t, kgen)
+
+	kigen, _ := NewKPermutationIndexGenerator(2, 4)
+	testClone[int](t, kigen)
+
+	mcgen, _ := NewMultiCombinationGenerator(3, items[:3], []int{2, 1, 2})
+	testClone[int](t, mcgen)
+
+	mcigen, _ := NewMultiCombinationIndexGenerator(3, []int{2, 1, 2})
+	testClone[int](t, mcigen)
+
+	sgen, _ := NewSubsetGenerator(1, 3, items)
+	testClone[int](t, sgen)
+
+	sigen, _ := NewSubsetIndexGenerator(1, 3, 4)
+	testClone[int](t, sigen)
+
+	ggen, _ := NewSubsetGenerator(0, 4, items, Gray())
+	testClone[int](t, ggen)
+
+	// a clone has its own destination slice
+	dest := make([]int, 2)
+	cgen.SetDest(dest)
+	cgen.Reset()
+	cgen.Next()
+	c := cgen.Clone()
+	c.Next()
+
+	if !slices.Equal(dest, cgen.Current()) || slices.Equal(dest, c.Current()) {
+		t.Errorf("Clone wrote into the original destination slice")
+	}
+}
@@ -5,7 +5,10 @@
 package kombinat
 
 import (
+	"encoding/json"
 	"fmt"
+	"iter"
+	"math/big"
 	"slices"
 )
 
@@ -13,125 +16,228 @@ func CombinationCount(m, n int) int {
 	return Binom(m, n)
 }
 
-// Combinations by the Chase's Twiddle algorithm (1970), based on [implementation in C]
-// by Matthew Belmonte in 1996.
+// CombinationCountChecked returns the number of combinations of size m out of n elements,
+// or [ErrOverflow] if it doesn't fit in an int.
+func CombinationCountChecked(m, n int) (int, error) {
+	return BinomChecked(m, n)
+}
+
+// CombinationCountBig returns the number of combinations of size m out of n elements as [big.Int].
+func CombinationCountBig(m, n int) *big.Int {
+	return BinomBig(m, n)
+}
+
+// CombinationRank returns the rank of a combination in the lexicographic order of
+// combinations of size len(positions) out of n elements. The combination is given as
+// strictly increasing positions of the chosen elements in the input slice.
 //
-// Returns error if the input slice is empty or nil, or if m is less than 1 or bigger than len(elems).
+// Returns an error if n < 1, if positions is empty or longer than n, or if
+// positions are out of range or not strictly increasing.
+func CombinationRank(n int, positions []int) (int, error) {
+	m := len(positions)
+
+	switch {
+	case m == 0:
+		return 0, fmt.Errorf("empty input slice")
+	case m > n:
+		return 0, fmt.Errorf("m is too large")
+	}
+
+	rank := 0
+	next := 0
+
+	for i, p := range positions {
+		if p < next || p >= n {
+			return 0, fmt.Errorf("positions are out of range or not increasing")
+		}
+
+		for ; next < p; next++ {
+			rank += Binom(m-i-1, n-next-1)
+		}
+
+		next = p + 1
+	}
+
+	return rank, nil
+}
+
+// CombinationUnrank returns strictly increasing positions of the combination at the
+// given rank in the lexicographic order of combinations of size m out of n elements.
+// It's the inverse of [CombinationRank].
 //
-// [implementation in C]: https://web.archive.org/web/20221024045742/http://www.netlib.no/netlib/toms/382
-func Combinations[T any](m int, elems []T) ([][]T, error) {
+// Returns an error if m is less than 1 or bigger than n, or if the rank is not
+// in the range [0, CombinationCount(m, n)).
+func CombinationUnrank(m, n, rank int) ([]int, error) {
 	switch {
 	case m <= 0:
 		return nil, fmt.Errorf("m must be >= 1")
-	case len(elems) == 0:
-		return nil, fmt.Errorf("empty input slice")
-	case m > len(elems):
+	case m > n:
 		return nil, fmt.Errorf("m is too large")
+	case rank < 0 || rank >= CombinationCount(m, n):
+		return nil, fmt.Errorf("rank out of range")
 	}
 
-	n := len(elems)
+	positions := make([]int, m)
+	combinationUnrank(m, n, rank, positions)
 
-	count := CombinationCount(m, len(elems))
-	res := make([][]T, 0, count)
+	return positions, nil
+}
 
-	// init twiddle
-	i := 0
-	p := make([]int, n+2)
+// Writes positions of the combination at the lexicographic rank into dest.
+func combinationUnrank(m, n, rank int, dest []int) {
+	p := 0
 
-	// current combination, init to elems[n-m:n]
-	c := slices.Clone(elems[n-m : n])
+	for i := 0; i < m; i++ {
+		for {
+			c := saturate(BinomChecked(m-i-1, n-p-1))
 
-	p[0] = n + 1
-	for i = 1; i != n-m+1; i++ {
-		p[i] = 0
-	}
+			if rank < c {
+				break
+			}
 
-	for i != n+1 {
-		p[i] = i + m - n
-		i++
+			rank -= c
+			p++
+		}
+
+		dest[i] = p
+		p++
 	}
+}
 
-	p[n+1] = -2
+// Combinations by the Chase's Twiddle algorithm (1970), based on [implementation in C]
+// by Matthew Belmonte in 1996.
+//
+// Returns error if the input slice is empty or nil, or if m is less than 1 or bigger than len(elems).
+//
+// [implementation in C]: https://web.archive.org/web/20221024045742/http://www.netlib.no/netlib/toms/382
+func Combinations[T any](m int, elems []T) ([][]T, error) {
+	return CombinationsWithLimit(m, elems)
+}
 
-	if m == 0 {
-		p[1] = 1
+// CombinationsWithLimit is like [Combinations], but returns [TooManyResultsError]
+// before allocating the results if there are more of them than allowed by opts.
+func CombinationsWithLimit[T any](m int, elems []T, opts ...LimitOption) ([][]T, error) {
+	t, err := CombinationsTable(m, elems, opts...)
+
+	if err != nil {
+		return nil, err
 	}
 
-	res = append(res, slices.Clone(c))
+	return t.Rows(), nil
+}
+
+// CombinationsTable is like [CombinationsWithLimit], but stores the results in a [Table].
+func CombinationsTable[T any](m int, elems []T, opts ...LimitOption) (*Table[T], error) {
+	gen, err := NewCombinationGenerator(m, elems)
 
-	// twiddle loop
-	var x, z, k int
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		j := 1
+	n := len(elems)
+	count, err := CombinationCountChecked(m, n)
 
-		for p[j] <= 0 {
-			j++
-		}
+	if err := checkLimit[T](count, err, m, opts, func() *big.Int { return CombinationCountBig(m, n) }); err != nil {
+		return nil, err
+	}
 
-		if p[j-1] == 0 {
-			for i := j - 1; i != 1; i-- {
-				p[i] = -1
-			}
-			p[j] = 0
-			x = 0
-			z = 0
-			p[1] = 1
-		} else {
-			if j > 1 {
-				p[j-1] = 0
+	return fillTable(gen, count, m), nil
+}
+
+// Restores the twiddle array p of size n+2 to the state it has at the given rank of
+// combinations of size m out of n elements.
+//
+// The sequence is split by the last position (the one that changes the least):
+// combinations that include it come first, followed by the ones that don't.
+// Both blocks recursively follow one of three orders, and the order of the
+// block depends on the parity of the remaining m:
+//
+//	A(n, m) = A(n-1, m-1)·m,  then A(n-1, m)·0 for even m, or B(n-1, m)·0 for odd m
+//	B(n, m) = A(n, m) for even m, otherwise B(n-1, m)·-1, then C(n-1, m-1)·m
+//	C(n, m) = A(n-1, m)·-1, then B(n-1, m-1)·m
+//
+// where the number after the dot is the value of p at the last position, and the
+// whole sequence follows the order A(n, m).
+func twiddleUnrank(n, m, rank int, p []int) {
+	const (
+		orderA = iota
+		orderB
+		orderC
+	)
+
+	order := orderA
+
+	for ; n > 0; n-- {
+		if m == 0 {
+			for i := 1; i <= n; i++ {
+				p[i] = 0
 			}
 
-			j++
-			for p[j] > 0 {
-				j++
+			if order == orderC {
+				p[n] = -1
 			}
 
-			k = j - 1
-			i = j
+			return
+		}
 
-			for p[i] == 0 {
-				p[i] = -1
-				i++
-			}
+		if order == orderB && m%2 == 0 {
+			order = orderA
+		}
 
-			if p[i] == -1 {
-				p[i] = p[k]
-				z = p[k] - 1
-				x = i - 1
-				p[k] = -1
+		switch order {
+		case orderA:
+			if c := saturate(BinomChecked(m-1, n-1)); rank < c {
+				p[n] = m
+				m--
 			} else {
-				if i == p[0] {
-					break
-				} else {
-					p[j] = p[i]
-					z = p[i] - 1
-					p[i] = 0
-					x = j - 1
+				p[n] = 0
+				rank -= c
+
+				if m%2 == 1 {
+					order = orderB
 				}
 			}
+		case orderB:
+			if c := saturate(BinomChecked(m, n-1)); rank < c {
+				p[n] = -1
+			} else {
+				p[n] = m
+				rank -= c
+				m--
+				order = orderC
+			}
+		case orderC:
+			if c := saturate(BinomChecked(m, n-1)); rank < c {
+				p[n] = -1
+				order = orderA
+			} else {
+				p[n] = m
+				rank -= c
+				m--
+				order = orderB
+			}
 		}
-
-		c[z] = elems[x]
-		res = append(res, slices.Clone(c))
 	}
-
-	return res, nil
 }
 
 // CombinationGenerator implements a [Generator] interface for generating combinations of
 // size m out of elements in elems slice on every invocation of the [CombinationGenerator.Next] method.
 // For algorithm details see [Combinations].
 type CombinationGenerator[T any] struct {
-	n, i, x, z, k, m int
-	p                []int
-	c, elems         []T
-	first, done      bool
+	n, i, x, z, k, m, pos, from, to int
+	p, lp, idx                      []int
+	c, elems                        []T
+	opts                            []GeneratorOption
+	first, done, lex, reverse       bool
+	change                          Change
 }
 
 // Init initializes a generator of combinations of size m out of elements in the elems slice.
-func (gen *CombinationGenerator[T]) Init(m int, elems []T) error {
-
+//
+// Supported options are [Lexicographic] and [Reverse]. With the [Lexicographic] option,
+// elements in every combination are in the order of the input slice, and consecutive
+// combinations can differ in more than one element.
+func (gen *CombinationGenerator[T]) Init(m int, elems []T, opts ...GeneratorOption) error {
 	switch {
 	case m <= 0:
 		return fmt.Errorf("m must be >= 1")
@@ -141,15 +247,37 @@ func (gen *CombinationGenerator[T]) Init(m int, elems []T) error {
 		return fmt.Errorf("m is too large")
 	}
 
+	return gen.init(m, len(elems), elems, opts)
+}
+
+// Initializes the generator for combinations of size m out of n elements. If elems
+// is nil, the generator is in index mode and produces positions instead of elements,
+// which requires T to be int.
+func (gen *CombinationGenerator[T]) init(m, n int, elems []T, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if o.gray {
+		return fmt.Errorf("gray code order is not supported")
+	}
+
 	gen.m = m
 	gen.elems = elems
 
 	// init twiddle
-	gen.n, gen.i = len(gen.elems), 0
+	gen.n, gen.i = n, 0
 	gen.p = make([]int, gen.n+2)
 
 	// current combination, init to elems[n-m:n]
-	gen.c = slices.Clone(gen.elems[gen.n-gen.m : gen.n])
+	gen.c = make([]T, gen.m)
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.c).([]int)
+	}
+
+	for i := range gen.m {
+		gen.set(i, gen.n-gen.m+i)
+	}
 
 	gen.p[0] = gen.n + 1
 	for gen.i = 1; gen.i != gen.n-gen.m+1; gen.i++ {
@@ -168,31 +296,89 @@ func (gen *CombinationGenerator[T]) Init(m int, elems []T) error {
 	}
 
 	gen.first = true
+	gen.change = Change{}
 	gen.done = false
 	gen.x, gen.z, gen.k = 0, 0, 0
+	gen.pos = -1
+	gen.opts = opts
+	gen.lex = o.lex
+	gen.reverse = o.reverse
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.lex {
+		gen.lp = indices(gen.m)
+		gen.fill(0)
+	}
+
+	if gen.reverse {
+		gen.end()
+	}
 
 	return nil
 }
 
-// Reset resets the generator to the beginning of the sequence.
+// Returns the number of combinations in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *CombinationGenerator[T]) count() int {
+	return saturate(CombinationCountChecked(gen.m, gen.n))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
 func (gen *CombinationGenerator[T]) Reset() {
-	s := gen.c
-	gen.Init(gen.m, gen.elems)
+	s, from, to := gen.c, gen.from, gen.to
+	gen.init(gen.m, gen.n, gen.elems, gen.opts)
 	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
 }
 
 // Next produces a new combination in the generator. If it returns false,
 // there are no more combinations available.
 func (gen *CombinationGenerator[T]) Next() bool {
-	if gen.done {
+	if gen.done || gen.pos+1 >= gen.to {
 		return false
 	}
 
 	if gen.first {
 		gen.first = false
+		gen.change = Change{Kind: ChangeAll}
+		gen.pos++
 		return true
 	}
 
+	if gen.lex {
+		// the rightmost position that can be incremented, and all after it
+		// follow it
+		i := gen.m - 1
+
+		for gen.lp[i] == gen.n-gen.m+i {
+			i--
+		}
+
+		gen.change = rangeChange(i, gen.m, gen.lp[i], gen.lp[i]+1)
+		gen.lp[i]++
+
+		for j := i + 1; j < gen.m; j++ {
+			gen.lp[j] = gen.lp[j-1] + 1
+		}
+
+		gen.fill(i)
+		gen.pos++
+		return true
+	}
+
+	// position of the element that leaves the combination
+	y := 0
 	j := 1
 
 	for gen.p[j] <= 0 {
@@ -204,6 +390,7 @@ func (gen *CombinationGenerator[T]) Next() bool {
 			gen.p[gen.i] = -1
 		}
 		gen.p[j] = 0
+		y = j - 1
 		gen.x = 0
 		gen.z = 0
 		gen.p[1] = 1
@@ -229,6 +416,7 @@ func (gen *CombinationGenerator[T]) Next() bool {
 			gen.p[gen.i] = gen.p[gen.k]
 			gen.z = gen.p[gen.k] - 1
 			gen.x = gen.i - 1
+			y = gen.k - 1
 			gen.p[gen.k] = -1
 		} else {
 			if gen.i == gen.p[0] {
@@ -238,15 +426,166 @@ func (gen *CombinationGenerator[T]) Next() bool {
 				gen.p[j] = gen.p[gen.i]
 				gen.z = gen.p[gen.i] - 1
 				gen.p[gen.i] = 0
+				y = gen.i - 1
 				gen.x = j - 1
 			}
 		}
 	}
 
-	gen.c[gen.z] = gen.elems[gen.x]
+	gen.set(gen.z, gen.x)
+	gen.change = Change{Kind: ChangeReplace, I: gen.z, Old: y, New: gen.x}
+	gen.pos++
+	return true
+}
+
+// Prev produces the combination before the current one in the sequence of the generator.
+// If it returns false, there are no previous combinations available in the range.
+// In the order of the Twiddle algorithm, Prev seeks to the previous combination, which
+// takes as much time as [CombinationGenerator.Seek], and [CombinationGenerator.LastChange]
+// reports [ChangeAll] after it. With the [Lexicographic] option, it steps back directly.
+func (gen *CombinationGenerator[T]) Prev() bool {
+	if !gen.lex || gen.change.Kind == ChangeNone {
+		return prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	if gen.pos-1 < gen.from {
+		return false
+	}
+
+	// the rightmost position that can be decremented, and all after it
+	// get the biggest positions
+	i := gen.m - 1
+
+	for i > 0 && gen.lp[i] == gen.lp[i-1]+1 {
+		i--
+	}
+
+	gen.change = rangeChange(i, gen.m, gen.lp[i], gen.lp[i]-1)
+	gen.lp[i]--
+
+	for j := i + 1; j < gen.m; j++ {
+		gen.lp[j] = gen.n - gen.m + j
+	}
+
+	gen.fill(i)
+	gen.done = false
+	gen.pos--
+
 	return true
 }
 
+// Writes elements at positions in lp into the current combination, starting from the position i.
+func (gen *CombinationGenerator[T]) fill(i int) {
+	for ; i < gen.m; i++ {
+		gen.set(i, gen.lp[i])
+	}
+}
+
+// Writes the element at position x into the position i of the current combination.
+func (gen *CombinationGenerator[T]) set(i, x int) {
+	if gen.idx != nil {
+		gen.idx[i] = x
+	} else {
+		gen.c[i] = gen.elems[x]
+	}
+}
+
+// LastChange returns the change of the current combination made by the last call to
+// [CombinationGenerator.Next]. Every combination after the first
+// one differs from the previous one in a single element ([ChangeReplace]).
+// With the [Lexicographic] option, combinations differ in the last element, or in
+// elements from some position to the end ([ChangeRange]).
+func (gen *CombinationGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current combination in the sequence of the generator,
+// or -1 if [CombinationGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [CombinationGenerator.Prev] is called.
+func (gen *CombinationGenerator[T]) Position() int {
+	return gen.pos
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [CombinationGenerator.Next] produces the combination
+// at that rank, and [CombinationGenerator.Prev]
+// the one before it. Seeking to the end of the range set by [CombinationGenerator.Range]
+// (CombinationCount(m, n) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *CombinationGenerator[T]) Seek(rank int) error {
+	count := gen.count()
+
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.first = rank < count
+	gen.done = !gen.first
+	gen.pos = rank - 1
+
+	if gen.done {
+		rank--
+	}
+
+	if gen.lex {
+		combinationUnrank(gen.m, gen.n, rank, gen.lp)
+		gen.fill(0)
+
+		return nil
+	}
+
+	twiddleUnrank(gen.n, gen.m, rank, gen.p)
+
+	for i := 1; i <= gen.n; i++ {
+		if gen.p[i] > 0 {
+			gen.set(gen.p[i]-1, i-1)
+		}
+	}
+
+	return nil
+}
+
+// Range limits the generator to the combinations with ranks in the range [from, to)
+// and moves it to the rank from. [CombinationGenerator.Reset] preserves the range, and
+// [CombinationGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, CombinationCount(m, n)].
+func (gen *CombinationGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *CombinationGenerator[T]) end() {
+	gen.pos = gen.to
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *CombinationGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
 // Current returns the internal slice that holds the current combination.
 // If you need to modify the returned slice, use [CombinationGenerator.CurrentCopy] instead.
 func (gen *CombinationGenerator[T]) Current() []T {
@@ -272,14 +611,181 @@ func (gen *CombinationGenerator[T]) SetDest(dest []T) error {
 	copy(dest, gen.c)
 	gen.c = dest
 
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
 	return nil
 }
 
+// All returns an iterator over all combinations paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [CombinationGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded combination and can be resumed with [CombinationGenerator.Next] or restarted with
+// [CombinationGenerator.Reset].
+func (gen *CombinationGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [CombinationGenerator.All], but it yields only the combinations.
+func (gen *CombinationGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following combinations, as if by calling
+// [CombinationGenerator.Next] and [CombinationGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of combinations written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *CombinationGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [CombinationGenerator.NextBatch], but writes the combinations one after another
+// into dst, where every combination takes m elements. Writes as many combinations as fit into dst.
+func (gen *CombinationGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.m)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler]. It encodes the state of the algorithm,
+// the position of the generator, its range and options, and the size m and the number of
+// elements, but not the elements, so the state can be restored only into a generator
+// initialized with the same input and options.
+func (gen *CombinationGenerator[T]) MarshalBinary() ([]byte, error) {
+	return gen.state().MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]. It restores the state encoded by
+// [CombinationGenerator.MarshalBinary] into the generator, which must be initialized with the
+// same input and options, so that [CombinationGenerator.Current] returns the same combination, and the following
+// call to [CombinationGenerator.Next] continues the sequence. Returns an error if the state is
+// invalid or if it doesn't match the generator.
+func (gen *CombinationGenerator[T]) UnmarshalBinary(data []byte) error {
+	st := new(generatorState)
+
+	if err := st.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// MarshalJSON implements [json.Marshaler]. It's the JSON equivalent of [CombinationGenerator.MarshalBinary].
+func (gen *CombinationGenerator[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gen.state())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It's the JSON equivalent of [CombinationGenerator.UnmarshalBinary].
+func (gen *CombinationGenerator[T]) UnmarshalJSON(data []byte) error {
+	st := new(generatorState)
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// Returns the checkpoint of the generator. Its variables are i, x, z and k of the Twiddle
+// algorithm and the first and done flags, and its slice is p of the algorithm, or lp with
+// the [Lexicographic] option.
+func (gen *CombinationGenerator[T]) state() *generatorState {
+	st := newState(gen.opts, gen.pos, gen.from, gen.to, gen.change, gen.m, gen.n)
+	st.Vars = []int{gen.i, gen.x, gen.z, gen.k, boolVar(gen.first), boolVar(gen.done)}
+	st.Slices = [][]int{slices.Clone(gen.p)}
+
+	if gen.lex {
+		st.Slices[0] = slices.Clone(gen.lp)
+	}
+
+	return st
+}
+
+// Restores the checkpoint made by state, and writes the current combination into dest.
+func (gen *CombinationGenerator[T]) load(st *generatorState) error {
+	l := gen.n + 2
+
+	if gen.lex {
+		l = gen.m
+	}
+
+	if err := st.check(gen.opts, gen.count(), []int{gen.m, gen.n}, 6, l); err != nil {
+		return err
+	}
+
+	v, p := st.Vars, st.Slices[0]
+
+	if !inRange(v[:4], 0, gen.n+1) || !inRange(v[4:], 0, 1) {
+		return fmt.Errorf("invalid state data")
+	}
+
+	if gen.lex {
+		// positions of elements in the combination increase
+		for i, x := range p {
+			if x < i || x > gen.n-gen.m+i || i > 0 && x <= p[i-1] {
+				return fmt.Errorf("invalid state data")
+			}
+		}
+	} else {
+		// slots of elements in the combination, with the sentinels at both ends
+		slots := make([]int, 0, gen.m)
+
+		for _, x := range p[1 : gen.n+1] {
+			if x > 0 {
+				slots = append(slots, x-1)
+			}
+		}
+
+		if p[0] != gen.n+1 || p[gen.n+1] != -2 || !inRange(p[1:gen.n+1], -1, gen.m) ||
+			len(slots) != gen.m || !isMultiset(slots, slices.Repeat([]int{1}, gen.m)) {
+			return fmt.Errorf("invalid state data")
+		}
+	}
+
+	gen.i, gen.x, gen.z, gen.k = v[0], v[1], v[2], v[3]
+	gen.first, gen.done = v[4] == 1, v[5] == 1
+	gen.pos, gen.from, gen.to = st.Pos, st.From, st.To
+	gen.change = st.Change
+
+	if gen.lex {
+		copy(gen.lp, p)
+		gen.fill(0)
+
+		return nil
+	}
+
+	copy(gen.p, p)
+
+	for i := 1; i <= gen.n; i++ {
+		if gen.p[i] > 0 {
+			gen.set(gen.p[i]-1, i-1)
+		}
+	}
+
+	return nil
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current combination, and shares only the input with the original.
+func (gen *CombinationGenerator[T]) Clone() *CombinationGenerator[T] {
+	c := *gen
+	c.p = slices.Clone(gen.p)
+	c.lp = slices.Clone(gen.lp)
+	c.c = slices.Clone(gen.c)
+
+	if gen.idx != nil {
+		c.idx = any(c.c).([]int)
+	}
+
+	return &c
+}
+
 // NewCombinationGenerator creates and initializes a new CombinationGenerator.
 // Arguments and returned errors are the same ones from the [CombinationGenerator.Init] method.
-func NewCombinationGenerator[T any](m int, elems []T) (*CombinationGenerator[T], error) {
+func NewCombinationGenerator[T any](m int, elems []T, opts ...GeneratorOption) (*CombinationGenerator[T], error) {
 	gen := new(CombinationGenerator[T])
-	err := gen.Init(m, elems)
+	err := gen.Init(m, elems, opts...)
 
 	if err != nil {
 		return nil, err
@@ -287,3 +793,43 @@ func NewCombinationGenerator[T any](m int, elems []T) (*CombinationGenerator[T],
 
 	return gen, nil
 }
+
+// NewCombinationIndexGenerator creates a new CombinationGenerator that produces combinations
+// of size m as positions of elements in an implicit input of size n, i.e. as integers in the
+// range [0, n), without requiring the input slice. Returns an error if m < 1, n < 1 or m > n,
+// or if the options can't be applied, see [CombinationGenerator.Init].
+func NewCombinationIndexGenerator(m, n int, opts ...GeneratorOption) (*CombinationGenerator[int], error) {
+	switch {
+	case m <= 0:
+		return nil, fmt.Errorf("m must be >= 1")
+	case n <= 0:
+		return nil, fmt.Errorf("n must be >= 1")
+	case m > n:
+		return nil, fmt.Errorf("m is too large")
+	}
+
+	gen := new(CombinationGenerator[int])
+
+	if err := gen.init(m, n, nil, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// CombinationsSeq returns an iterator over combinations of size m out of elements in
+// the elems slice, paired with their rank. The sequence is empty if the arguments
+// are invalid, see [CombinationGenerator.Init] for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func CombinationsSeq[T any](m int, elems []T) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewCombinationGenerator(m, elems)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
@@ -5,6 +5,7 @@
 package kombinat
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"testing"
@@ -50,6 +51,61 @@ func TestCombinationCount(t *testing.T) {
 	if n := CombinationCount(4, 4); n != 1 {
 		t.Errorf("Want 1, got %v", n)
 	}
+	if _, err := CombinationCountChecked(50, 100); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Want ErrOverflow, got %v", err)
+	}
+	if n := CombinationCountBig(50, 100).String(); n != "100891344545564193334812497256" {
+		t.Errorf("Want 100891344545564193334812497256, got %v", n)
+	}
+}
+
+func TestCombinationRank(t *testing.T) {
+	for k, want := range _combi_table {
+		k := k
+		want := want
+
+		t.Run(fmt.Sprintf("c(%d,4)=%d", k, len(want)), func(t *testing.T) {
+			for r, w := range want {
+				positions, err := CombinationUnrank(k, 4, r)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				for i, p := range positions {
+					if _combi_items[p] != w[i] {
+						t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", r, positions, w)
+						break
+					}
+				}
+
+				if rank, err := CombinationRank(4, positions); err != nil || rank != r {
+					t.Errorf("Wrong rank for %v, got: %v (%v), want: %v", positions, rank, err, r)
+				}
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := CombinationUnrank(2, 4, 6); err == nil {
+			t.Errorf("Didn't err on rank out of range")
+		}
+		if _, err := CombinationUnrank(2, 4, -1); err == nil {
+			t.Errorf("Didn't err on negative rank")
+		}
+		if _, err := CombinationUnrank(0, 4, 0); err == nil {
+			t.Errorf("Didn't err on m = 0")
+		}
+		if _, err := CombinationRank(4, []int{1, 1}); err == nil {
+			t.Errorf("Didn't err on repeated positions")
+		}
+		if _, err := CombinationRank(4, []int{2, 1}); err == nil {
+			t.Errorf("Didn't err on decreasing positions")
+		}
+		if _, err := CombinationRank(4, []int{1, 4}); err == nil {
+			t.Errorf("Didn't err on position out of range")
+		}
+	})
 }
 
 func TestCombinations(t *testing.T) {
@@ -135,6 +191,194 @@ func TestCombinationGenerator(t *testing.T) {
 	}
 }
 
+func TestCombinationIndexGenerator(t *testing.T) {
+	for n := 1; n <= 5; n++ {
+		for m := 1; m <= n; m++ {
+			gen, err := NewCombinationIndexGenerator(m, n)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			want, _ := Combinations(m, indices(n))
+			testGenerator[int](t, gen, want)
+		}
+	}
+
+	gen, _ := NewCombinationIndexGenerator(2, 4, Reverse())
+	want, _ := Combinations(2, indices(4))
+
+	if !gen.Prev() || !slices.Equal(gen.Current(), want[len(want)-1]) {
+		t.Errorf("Not at the end with Reverse, got: %v, want: %v", gen.Current(), want[len(want)-1])
+	}
+
+	if _, err := NewCombinationIndexGenerator(1, 0); err == nil {
+		t.Errorf("Didn't err on n = 0")
+	}
+}
+
+func TestCombinationGeneratorLexicographic(t *testing.T) {
+	items := []string{"A", "B", "C", "D", "E"}
+
+	for m := 1; m <= len(items); m++ {
+		want := make([][]string, CombinationCount(m, len(items)))
+
+		for r := range want {
+			positions, _ := CombinationUnrank(m, len(items), r)
+			want[r] = ApplyCopy(positions, items)
+		}
+
+		gen, err := NewCombinationGenerator(m, items, Lexicographic())
+
+		if err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		testGenerator[string](t, gen, want)
+
+		if len(want) > 1 {
+			gen.Reset()
+			testChanges[string](t, gen, items)
+		}
+
+		testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[string] {
+			gen, _ := NewCombinationGenerator(m, items, append(opts, Lexicographic())...)
+			return gen
+		})
+	}
+}
+
+func TestCombinationGeneratorAll(t *testing.T) {
+	gen, _ := NewCombinationGenerator(2, _combi_items)
+	want := make([][]int, 0, CombinationCount(2, 4))
+
+	for gen.Next() {
+		want = append(want, gen.CurrentCopy())
+	}
+
+	res := make([][]int, 0, len(want))
+
+	for i, c := range gen.All() {
+		if i != len(res) {
+			t.Errorf("Wrong rank, got: %v, want: %v", i, len(res))
+		}
+		res = append(res, slices.Clone(c))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, want)
+	}
+
+	res = res[:0]
+
+	for c := range gen.Values() {
+		res = append(res, slices.Clone(c))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal (values), \ngot: %v, \nwant: %v", res, want)
+	}
+
+	// break + resume
+	for i := range gen.All() {
+		if i == 2 {
+			break
+		}
+	}
+
+	if gen.Next(); slices.Compare(gen.Current(), want[3]) != 0 {
+		t.Errorf("Not resumed after break, got: %v, want: %v", gen.Current(), want[3])
+	}
+
+	gen.Reset()
+
+	if gen.Next(); slices.Compare(gen.Current(), want[0]) != 0 {
+		t.Errorf("Not reset after break, got: %v, want: %v", gen.Current(), want[0])
+	}
+}
+
+func TestCombinationsSeq(t *testing.T) {
+	for k, want := range _combi_table {
+		k := k
+		want := want
+
+		t.Run(fmt.Sprintf("c(%d,4)=%d", k, CombinationCount(k, 4)), func(t *testing.T) {
+			res := make([][]int, 0, len(want))
+
+			for i, c := range CombinationsSeq(k, _combi_items) {
+				if i != len(res) {
+					t.Errorf("Wrong rank, got: %v, want: %v", i, len(res))
+				}
+				res = append(res, slices.Clone(c))
+			}
+
+			slices.SortFunc(res, func(e1, e2 []int) int {
+				return slices.Compare(e1, e2)
+			})
+
+			if compareSliceOfSlices(res, want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, want)
+			}
+		})
+	}
+
+	for range CombinationsSeq(5, _combi_items) {
+		t.Errorf("Didn't return an empty sequence on invalid input")
+	}
+}
+
+func TestCombinationGeneratorSeek(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	for n := 1; n <= len(items); n++ {
+		for m := 1; m <= n; m++ {
+			gen, _ := NewCombinationGenerator(m, items[:n])
+			want := make([][]int, 0, CombinationCount(m, n))
+
+			for gen.Next() {
+				if gen.Position() != len(want) {
+					t.Errorf("c(%d,%d): wrong position, got: %v, want: %v", m, n, gen.Position(), len(want))
+				}
+				want = append(want, gen.CurrentCopy())
+			}
+
+			for r := range want {
+				if err := gen.Seek(r); err != nil {
+					t.Fatalf("c(%d,%d): error'd with: %v", m, n, err)
+				}
+
+				if gen.Position() != r-1 {
+					t.Errorf("c(%d,%d): wrong position after seek, got: %v, want: %v", m, n, gen.Position(), r-1)
+				}
+
+				for i := r; i < len(want); i++ {
+					if !gen.Next() || slices.Compare(gen.Current(), want[i]) != 0 {
+						t.Fatalf("c(%d,%d): not equal at %v after seek to %v, \ngot: %v, \nwant: %v", m, n, i, r, gen.Current(), want[i])
+					}
+				}
+
+				if gen.Next() {
+					t.Errorf("c(%d,%d): didn't return false on end after seek to %v", m, n, r)
+				}
+			}
+
+			if err := gen.Seek(len(want)); err != nil || gen.Next() {
+				t.Errorf("c(%d,%d): didn't seek to end", m, n)
+			}
+			if gen.Seek(len(want)+1) == nil || gen.Seek(-1) == nil {
+				t.Errorf("c(%d,%d): didn't err on rank out of range", m, n)
+			}
+		}
+	}
+}
+
+func TestCombinationGeneratorShard(t *testing.T) {
+	for m := 1; m <= 6; m++ {
+		gen, _ := NewCombinationGenerator(m, []int{1, 2, 3, 4, 5, 6})
+		testShards[int](t, gen)
+	}
+}
+
 func BenchmarkCombinations(b *testing.B) {
 	items := []int{1, 2, 3, 4, 5, 6}
 
@@ -0,0 +1,314 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+// CoolLexGenerator generates combinations of m out of n elements as bitmasks, where the
+// bit i is set if the element at position i is in the combination. Masks are produced
+// in the cool-lex order, described in "The Coolest Way to Generate Combinations" by
+// Frank Ruskey and Aaron Williams, 2009, where every mask after the first one is made
+// from the previous one by moving one or two bits, in constant time.
+//
+// The first mask has the lowest m bits set. Use [CoolLexBitsetGenerator] if n > 64.
+type CoolLexGenerator struct {
+	m, n, pos int
+	x         uint64
+}
+
+// Init initializes a generator of combinations of m out of n elements.
+// Returns an error if m < 1, if m > n, or if n > 64.
+func (gen *CoolLexGenerator) Init(m, n int) error {
+	if err := checkCoolLex(m, n); err != nil {
+		return err
+	}
+
+	if n > 64 {
+		return fmt.Errorf("n must be <= 64")
+	}
+
+	gen.m, gen.n = m, n
+	gen.Reset()
+
+	return nil
+}
+
+// Checks the arguments of cool-lex generators.
+func checkCoolLex(m, n int) error {
+	switch {
+	case m <= 0:
+		return fmt.Errorf("m must be >= 1")
+	case m > n:
+		return fmt.Errorf("m is too large")
+	}
+
+	return nil
+}
+
+// Reset resets the generator to the beginning of the sequence.
+func (gen *CoolLexGenerator) Reset() {
+	gen.pos = -1
+	gen.x = ^uint64(0) >> (64 - gen.m)
+}
+
+// Current returns the current combination as a bitmask.
+func (gen *CoolLexGenerator) Current() uint64 {
+	return gen.x
+}
+
+// Next produces a new combination in the generator. If it returns false,
+// there are no more combinations available.
+func (gen *CoolLexGenerator) Next() bool {
+	if gen.n == 0 {
+		return false
+	}
+
+	if gen.pos < 0 {
+		gen.pos = 0
+		return true
+	}
+
+	// the mask read from the lowest bit is a run of a ones, a run of zeros, and
+	// the rest that starts with the bit q
+	x := gen.x
+	a := bits.TrailingZeros64(^x)
+	q := bits.TrailingZeros64(x >> a << a)
+
+	// the mask before the first one is made of m-1 ones, zeros and the last bit
+	if a == gen.n || a == gen.m-1 && q == gen.n-1 {
+		return false
+	}
+
+	if q < gen.n-1 && x&(1<<(q+1)) == 0 || q >= gen.n {
+		if a > 0 {
+			x ^= 1 | 1<<a
+		}
+		if q < gen.n {
+			x ^= 1<<q | 1<<(q+1)
+		}
+	} else {
+		x ^= 1<<q | 1<<a
+	}
+
+	gen.x = x
+	gen.pos++
+
+	return true
+}
+
+// Position returns the rank of the current combination in the sequence of the generator,
+// or -1 if [CoolLexGenerator.Next] hasn't been called yet.
+func (gen *CoolLexGenerator) Position() int {
+	return gen.pos
+}
+
+// All returns an iterator over all combinations paired with their rank, starting from
+// the beginning of the sequence. If the loop is stopped early, the generator stays on the
+// last yielded combination and can be resumed with [CoolLexGenerator.Next] or restarted
+// with [CoolLexGenerator.Reset].
+func (gen *CoolLexGenerator) All() iter.Seq2[int, uint64] {
+	return func(yield func(int, uint64) bool) {
+		gen.Reset()
+
+		for gen.Next() {
+			if !yield(gen.pos, gen.x) {
+				return
+			}
+		}
+	}
+}
+
+// Values is like [CoolLexGenerator.All], but it yields only the combinations.
+func (gen *CoolLexGenerator) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for _, x := range gen.All() {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
+// NewCoolLexGenerator creates and initializes a new CoolLexGenerator.
+// Arguments and returned errors are the same ones from the [CoolLexGenerator.Init] method.
+func NewCoolLexGenerator(m, n int) (*CoolLexGenerator, error) {
+	gen := new(CoolLexGenerator)
+	err := gen.Init(m, n)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// CoolLexBitsetGenerator is like [CoolLexGenerator], but it produces combinations as
+// bitsets of any size, stored in a slice of (n+63)/64 words, where the bit i is the bit
+// i%64 of the word i/64. Finding the bits to move takes time proportional to the number
+// of words they span.
+type CoolLexBitsetGenerator struct {
+	m, n, pos int
+	words     []uint64
+}
+
+// Init initializes a generator of combinations of m out of n elements.
+// Returns an error if m < 1 or if m > n.
+func (gen *CoolLexBitsetGenerator) Init(m, n int) error {
+	if err := checkCoolLex(m, n); err != nil {
+		return err
+	}
+
+	gen.m, gen.n = m, n
+	gen.words = make([]uint64, (n+63)/64)
+	gen.Reset()
+
+	return nil
+}
+
+// Reset resets the generator to the beginning of the sequence.
+func (gen *CoolLexBitsetGenerator) Reset() {
+	gen.pos = -1
+	clear(gen.words)
+
+	for i := 0; i < gen.m; i++ {
+		flipBit(gen.words, i)
+	}
+}
+
+// Current returns the internal slice that holds the current combination.
+// If you need to modify the returned slice, use [CoolLexBitsetGenerator.CurrentCopy] instead.
+func (gen *CoolLexBitsetGenerator) Current() []uint64 {
+	return gen.words
+}
+
+// CurrentCopy returns a copy of the internal slice that holds the current combination.
+// If you don't need to modify the returned slice, use [CoolLexBitsetGenerator.Current] to avoid allocation.
+func (gen *CoolLexBitsetGenerator) CurrentCopy() []uint64 {
+	return slices.Clone(gen.words)
+}
+
+// Next produces a new combination in the generator. If it returns false,
+// there are no more combinations available.
+func (gen *CoolLexBitsetGenerator) Next() bool {
+	if gen.n == 0 {
+		return false
+	}
+
+	if gen.pos < 0 {
+		gen.pos = 0
+		return true
+	}
+
+	// the same steps as in CoolLexGenerator.Next
+	w := gen.words
+	a := findBit(w, 0, false)
+	q := findBit(w, a, true)
+
+	if a == gen.n || a == gen.m-1 && q == gen.n-1 {
+		return false
+	}
+
+	if q < gen.n-1 && !hasBit(w, q+1) || q >= gen.n {
+		if a > 0 {
+			flipBit(w, 0)
+			flipBit(w, a)
+		}
+		if q < gen.n {
+			flipBit(w, q)
+			flipBit(w, q+1)
+		}
+	} else {
+		flipBit(w, q)
+		flipBit(w, a)
+	}
+
+	gen.pos++
+
+	return true
+}
+
+// Position returns the rank of the current combination in the sequence of the generator,
+// or -1 if [CoolLexBitsetGenerator.Next] hasn't been called yet.
+func (gen *CoolLexBitsetGenerator) Position() int {
+	return gen.pos
+}
+
+// All returns an iterator over all combinations paired with their rank, starting from
+// the beginning of the sequence. The yielded slice is the one returned by
+// [CoolLexBitsetGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded combination and can be resumed with [CoolLexBitsetGenerator.Next] or restarted
+// with [CoolLexBitsetGenerator.Reset].
+func (gen *CoolLexBitsetGenerator) All() iter.Seq2[int, []uint64] {
+	return func(yield func(int, []uint64) bool) {
+		gen.Reset()
+
+		for gen.Next() {
+			if !yield(gen.pos, gen.words) {
+				return
+			}
+		}
+	}
+}
+
+// Values is like [CoolLexBitsetGenerator.All], but it yields only the combinations.
+func (gen *CoolLexBitsetGenerator) Values() iter.Seq[[]uint64] {
+	return func(yield func([]uint64) bool) {
+		for _, w := range gen.All() {
+			if !yield(w) {
+				return
+			}
+		}
+	}
+}
+
+// NewCoolLexBitsetGenerator creates and initializes a new CoolLexBitsetGenerator.
+// Arguments and returned errors are the same ones from the [CoolLexBitsetGenerator.Init] method.
+func NewCoolLexBitsetGenerator(m, n int) (*CoolLexBitsetGenerator, error) {
+	gen := new(CoolLexBitsetGenerator)
+	err := gen.Init(m, n)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// Returns the index of the first bit in words from the index i that's set if set is true,
+// or unset otherwise, or len(words)*64 if there's none.
+func findBit(words []uint64, i int, set bool) int {
+	for w := i / 64; w < len(words); w++ {
+		x := words[w]
+
+		if !set {
+			x = ^x
+		}
+
+		if w == i/64 {
+			x &= ^uint64(0) << (i % 64)
+		}
+
+		if x != 0 {
+			return w*64 + bits.TrailingZeros64(x)
+		}
+	}
+
+	return len(words) * 64
+}
+
+// Reports whether the bit i is set in words.
+func hasBit(words []uint64, i int) bool {
+	return words[i/64]&(1<<(i%64)) != 0
+}
+
+// Flips the bit i in words.
+func flipBit(words []uint64, i int) {
+	words[i/64] ^= 1 << (i % 64)
+}
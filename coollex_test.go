@@ -0,0 +1,192 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"math/bits"
+	"slices"
+	"testing"
+)
+
+// Returns combinations of m out of n elements in the cool-lex order by its definition:
+// the next string of bits is made by rotating the shortest prefix that ends with 010 or 011
+// one position to the right, or the entire string if there's no such prefix.
+func coolLexStrings(m, n int) [][]bool {
+	b := make([]bool, n)
+
+	for i := range m {
+		b[i] = true
+	}
+
+	res := [][]bool{slices.Clone(b)}
+
+	for range CombinationCount(m, n) - 1 {
+		p := n
+
+		for i := 2; i < n; i++ {
+			if !b[i-2] && b[i-1] {
+				p = i + 1
+				break
+			}
+		}
+
+		last := b[p-1]
+		copy(b[1:p], b[:p-1])
+		b[0] = last
+		res = append(res, slices.Clone(b))
+	}
+
+	return res
+}
+
+func bitsetOf(b []bool) []uint64 {
+	words := make([]uint64, (len(b)+63)/64)
+
+	for i, set := range b {
+		if set {
+			flipBit(words, i)
+		}
+	}
+
+	return words
+}
+
+func TestCoolLexGenerator(t *testing.T) {
+	gen, err := NewCoolLexGenerator(2, 4)
+
+	if err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	res := slices.Collect(gen.Values())
+
+	if want := []uint64{0b0011, 0b0110, 0b0101, 0b1010, 0b1100, 0b1001}; !slices.Equal(res, want) {
+		t.Errorf("Not equal, \ngot: %b, \nwant: %b", res, want)
+	}
+
+	for n := 1; n <= 10; n++ {
+		for m := 1; m <= n; m++ {
+			t.Run(fmt.Sprintf("c(%d,%d)", m, n), func(t *testing.T) {
+				want := coolLexStrings(m, n)
+				gen, _ := NewCoolLexGenerator(m, n)
+
+				for i := 0; i < 2; i++ {
+					for r := range want {
+						if !gen.Next() || gen.Position() != r || gen.Current() != bitsetOf(want[r])[0] {
+							t.Fatalf("Not equal at %v, \ngot: %b, \nwant: %b", r, gen.Current(), bitsetOf(want[r])[0])
+						}
+					}
+
+					if gen.Next() || gen.Next() {
+						t.Errorf("Didn't return false on end")
+					}
+
+					gen.Reset()
+				}
+			})
+		}
+	}
+
+	for _, x := range []int{0, 3} {
+		if _, err := NewCoolLexGenerator(x, 2); err == nil {
+			t.Errorf("Didn't err on m = %v", x)
+		}
+	}
+
+	gen, _ = NewCoolLexGenerator(64, 64)
+
+	if !gen.Next() || gen.Current() != ^uint64(0) || gen.Next() {
+		t.Errorf("Wrong combinations of 64 out of 64 elements")
+	}
+
+	if _, err := NewCoolLexGenerator(2, 65); err == nil {
+		t.Errorf("Didn't err on n = 65")
+	}
+}
+
+func TestCoolLexBitsetGenerator(t *testing.T) {
+	for _, c := range [][2]int{{1, 1}, {2, 4}, {3, 6}, {1, 64}, {2, 64}, {63, 64}, {64, 64}, {1, 65}, {2, 66}, {64, 66}, {3, 130}} {
+		m, n := c[0], c[1]
+
+		t.Run(fmt.Sprintf("c(%d,%d)", m, n), func(t *testing.T) {
+			want := coolLexStrings(m, n)
+			gen, err := NewCoolLexBitsetGenerator(m, n)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			res := make([][]uint64, 0, len(want))
+
+			for r, words := range gen.All() {
+				if r != len(res) {
+					t.Errorf("Wrong rank, got: %v, want: %v", r, len(res))
+				}
+				if w := bitsetOf(want[r]); !slices.Equal(words, w) {
+					t.Fatalf("Not equal at %v, \ngot: %b, \nwant: %b", r, words, w)
+				}
+
+				res = append(res, gen.CurrentCopy())
+			}
+
+			if len(res) != len(want) || gen.Next() {
+				t.Errorf("Wrong number of combinations, got: %v, want: %v", len(res), len(want))
+			}
+
+			if n > 64 {
+				return
+			}
+
+			// the same sequence as the one of masks
+			mgen, _ := NewCoolLexGenerator(m, n)
+
+			for _, words := range res {
+				if !mgen.Next() || mgen.Current() != words[0] {
+					t.Fatalf("Not equal at %v, \ngot: %b, \nwant: %b", mgen.Position(), mgen.Current(), words[0])
+				}
+			}
+		})
+	}
+
+	// every step moves at most two bits
+	gen, _ := NewCoolLexBitsetGenerator(5, 130)
+	prev := gen.CurrentCopy()
+
+	for i := 0; i < 1000 && gen.Next(); i++ {
+		diff := 0
+
+		for w, x := range gen.Current() {
+			diff += bits.OnesCount64(x ^ prev[w])
+		}
+
+		if diff > 4 {
+			t.Fatalf("Moved more than two bits at %v, \ngot: %b, \nprev: %b", gen.Position(), gen.Current(), prev)
+		}
+
+		copy(prev, gen.Current())
+	}
+
+	if _, err := NewCoolLexBitsetGenerator(3, 2); err == nil {
+		t.Errorf("Didn't err on m > n")
+	}
+}
+
+func BenchmarkCoolLexGenerator(b *testing.B) {
+	for k := 2; k <= 6; k++ {
+		k := k
+
+		b.Run(fmt.Sprintf("c(%d,6)=%d", k, CombinationCount(k, 6)), func(b *testing.B) {
+			gen := new(CoolLexGenerator)
+
+			for i := 0; i < b.N; i++ {
+				gen.Init(k, 6)
+				for gen.Next() {
+					gen.Current()
+				}
+			}
+		})
+	}
+}
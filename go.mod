@@ -1,3 +1,3 @@
 module github.com/drazengolic/kombinat
 
-go 1.21.5
+go 1.23
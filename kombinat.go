@@ -5,7 +5,11 @@
 package kombinat
 
 import (
+	"errors"
 	"fmt"
+	"iter"
+	"math"
+	"math/big"
 )
 
 type Generator[T any] interface {
@@ -16,6 +20,20 @@ type Generator[T any] interface {
 	SetDest([]T) error
 }
 
+// SeekableGenerator is a [Generator] that can jump to any rank in its sequence,
+// and that can be limited to a range of ranks. All generators in this package
+// implement it.
+type SeekableGenerator[T any] interface {
+	Generator[T]
+	Position() int
+	Seek(rank int) error
+	Range(from, to int) error
+	Shard(i, total int) error
+}
+
+// ErrOverflow is returned by checked counting functions when the result doesn't fit in an int.
+var ErrOverflow = errors.New("integer overflow")
+
 // IntPow calculates n^m as integer
 func IntPow(n, m int) int {
 	if m == 0 {
@@ -31,6 +49,34 @@ func IntPow(n, m int) int {
 	return result
 }
 
+// IntPowChecked calculates n^m as integer, or returns [ErrOverflow] if the result doesn't fit in an int.
+func IntPowChecked(n, m int) (int, error) {
+	if m == 0 {
+		return 1, nil
+	}
+
+	result := n
+
+	for i := 2; i <= m; i++ {
+		var ok bool
+
+		if result, ok = mul(result, n); !ok {
+			return 0, ErrOverflow
+		}
+	}
+
+	return result, nil
+}
+
+// IntPowBig calculates n^m as [big.Int].
+func IntPowBig(n, m int) *big.Int {
+	if m < 0 {
+		return big.NewInt(int64(n))
+	}
+
+	return new(big.Int).Exp(big.NewInt(int64(n)), big.NewInt(int64(m)), nil)
+}
+
 // Fac calculates factorial of n (n!)
 func Fac(n int) int {
 	ret := 1
@@ -43,24 +89,340 @@ func Fac(n int) int {
 	return ret
 }
 
+// FacChecked calculates factorial of n (n!), or returns [ErrOverflow] if the result doesn't fit in an int.
+func FacChecked(n int) (int, error) {
+	ret := 1
+
+	for n > 1 {
+		var ok bool
+
+		if ret, ok = mul(ret, n); !ok {
+			return 0, ErrOverflow
+		}
+
+		n--
+	}
+
+	return ret, nil
+}
+
+// FacBig calculates factorial of n (n!) as [big.Int].
+func FacBig(n int) *big.Int {
+	if n < 2 {
+		return big.NewInt(1)
+	}
+
+	return new(big.Int).MulRange(2, int64(n))
+}
+
 // Binom calculates the [binomial coefficient].
+// Intermediate results never exceed the final one, so it overflows only if the result doesn't fit in an int.
 //
 // [binomial coefficient]: https://en.wikipedia.org/wiki/Binomial_coefficient
 func Binom(k, n int) int {
-	num := 1
-	denom := 1
+	r, _ := binom(k, n)
+	return r
+}
 
-	for k > 0 {
-		num *= n
-		denom *= k
-		n--
-		k--
+// BinomChecked calculates the [binomial coefficient], or returns [ErrOverflow] if the result doesn't fit in an int.
+//
+// [binomial coefficient]: https://en.wikipedia.org/wiki/Binomial_coefficient
+func BinomChecked(k, n int) (int, error) {
+	r, ok := binom(k, n)
+
+	if !ok {
+		return 0, ErrOverflow
+	}
+
+	return r, nil
+}
+
+// BinomBig calculates the [binomial coefficient] as [big.Int].
+//
+// [binomial coefficient]: https://en.wikipedia.org/wiki/Binomial_coefficient
+func BinomBig(k, n int) *big.Int {
+	switch {
+	case k <= 0:
+		return big.NewInt(1)
+	case k > n:
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Binomial(int64(n), int64(k))
+}
+
+// Multinomial calculates the [multinomial coefficient] for the given numbers of
+// repetitions, i.e. (k1+k2+...)! / (k1! * k2! * ...).
+// Intermediate results never exceed the final one, so it overflows only if the result doesn't fit in an int.
+//
+// [multinomial coefficient]: https://en.wikipedia.org/wiki/Multinomial_theorem#Multinomial_coefficients
+func Multinomial(reps []int) int {
+	r, _ := multinomial(reps)
+	return r
+}
+
+// MultinomialChecked calculates the [multinomial coefficient] for the given numbers of
+// repetitions, or returns [ErrOverflow] if the result doesn't fit in an int.
+//
+// [multinomial coefficient]: https://en.wikipedia.org/wiki/Multinomial_theorem#Multinomial_coefficients
+func MultinomialChecked(reps []int) (int, error) {
+	r, ok := multinomial(reps)
+
+	if !ok {
+		return 0, ErrOverflow
+	}
+
+	return r, nil
+}
+
+// MultinomialBig calculates the [multinomial coefficient] for the given numbers of
+// repetitions as [big.Int].
+//
+// [multinomial coefficient]: https://en.wikipedia.org/wiki/Multinomial_theorem#Multinomial_coefficients
+func MultinomialBig(reps []int) *big.Int {
+	ret := big.NewInt(1)
+	n := 0
+
+	for _, k := range reps {
+		n += k
+		ret.Mul(ret, BinomBig(k, n))
+	}
+
+	return ret
+}
+
+// Multiplies a and b, reporting whether the result fits in an int.
+func mul(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
 	}
 
-	return num / denom
+	c := a * b
+
+	if c/b != a || (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) {
+		return c, false
+	}
+
+	return c, true
+}
+
+// Calculates the binomial coefficient with the multiplicative formula, reducing the
+// running result and the divisor by their GCD before every multiplication, so that
+// every intermediate result is a binomial coefficient not larger than the final one.
+// Reports whether the result fits in an int.
+func binom(k, n int) (int, bool) {
+	switch {
+	case k <= 0:
+		return 1, true
+	case k > n:
+		return 0, true
+	}
+
+	k = min(k, n-k)
+	ret := 1
+
+	for i := 1; i <= k; i++ {
+		g := gcd(ret, i)
+
+		var ok bool
+
+		if ret, ok = mul(ret/g, (n-k+i)/(i/g)); !ok {
+			return ret, false
+		}
+	}
+
+	return ret, true
+}
+
+// Calculates the multinomial coefficient as a product of binomial coefficients,
+// reporting whether the result fits in an int.
+func multinomial(reps []int) (int, bool) {
+	ret := 1
+	n := 0
+	valid := true
+
+	for _, k := range reps {
+		n += k
+		b, ok := binom(k, n)
+		valid = valid && ok
+		ret, ok = mul(ret, b)
+		valid = valid && ok
+	}
+
+	return ret, valid
+}
+
+// Greatest common divisor of a and b.
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// Returns count, or math.MaxInt if it doesn't fit in an int, as reported by err.
+// Generators use saturated counts as the default end of their range and as weights
+// of ranks, so that a sequence longer than math.MaxInt can still be iterated and
+// sought within its first math.MaxInt results.
+func saturate(count int, err error) int {
+	if err != nil {
+		return math.MaxInt
+	}
+
+	return count
+}
+
+// Adds non-negative a and b, saturating the result to math.MaxInt like saturate.
+func satAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+
+	return a + b
+}
+
+// Checks if a generator with options o can produce a sequence of count results,
+// where count is saturated. The end of a longer sequence can't be reached, so it
+// can't be reversed.
+func checkCount(o genOptions, count int) error {
+	if o.reverse && count == math.MaxInt {
+		return fmt.Errorf("too many results to reverse: %w", ErrOverflow)
+	}
+
+	return nil
 }
 
 // Creates a low capacity message for generators to panic about it.
 func capacityMsg(need, got int) string {
 	return fmt.Sprintf("Not enough capacity in the destination slice (need %d, got %d)", need, got)
 }
+
+// Returns the slice of positions [0, n).
+func indices(n int) []int {
+	s := make([]int, n)
+
+	for i := range s {
+		s[i] = i
+	}
+
+	return s
+}
+
+// A generator that can walk its sequence in both directions.
+type reversibleGenerator[T any] interface {
+	SeekableGenerator[T]
+	LastChange() Change
+	Prev() bool
+}
+
+// Creates an iterator over the generator's sequence from the beginning, or backwards
+// from the end if the generator has the Reverse option.
+func values[T any](gen reversibleGenerator[T], reverse bool) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for _, v := range all(gen, reverse) {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Creates an iterator over the generator's sequence from the beginning, or backwards
+// from the end if the generator has the Reverse option, paired with the rank of every item.
+func all[T any](gen reversibleGenerator[T], reverse bool) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen.Reset()
+		step := gen.Next
+
+		if reverse {
+			step = gen.Prev
+		}
+
+		for step() {
+			if !yield(gen.Position(), gen.Current()) {
+				return
+			}
+		}
+	}
+}
+
+// Moves the generator to the result before the current one by seeking to it, for
+// generators that don't step back directly. Right after Seek there's no current
+// result, so it moves to the result before the sought rank, or to the last one if the
+// generator is at the end of its range. Returns false if there's no such result in
+// the range [from, to).
+func prevBySeek[T any](gen reversibleGenerator[T], from, to int) bool {
+	rank := gen.Position() - 1
+
+	if gen.LastChange().Kind == ChangeNone {
+		rank = min(rank+1, to-1)
+	}
+
+	if rank < from || gen.Seek(rank) != nil {
+		return false
+	}
+
+	return gen.Next()
+}
+
+// Fills dst with the following results of the generator, reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of results written.
+func nextBatch[T any](gen Generator[T], dst [][]T) int {
+	n := 0
+
+	for n < len(dst) && gen.Next() {
+		dst[n] = append(dst[n][:0], gen.Current()...)
+		n++
+	}
+
+	return n
+}
+
+// Fills dst with the following results of the generator, one after another, where every
+// result takes width elements and smaller results are padded with zero values.
+// Returns the number of results written.
+func nextBatchFlat[T any](gen Generator[T], dst []T, width int) int {
+	n := 0
+
+	// results of zero width, i.e. empty subsets, always fit
+	for (n+1)*width <= len(dst) && gen.Next() {
+		row := dst[n*width : (n+1)*width]
+		clear(row[copy(row, gen.Current()):])
+		n++
+	}
+
+	return n
+}
+
+// Checks if [from, to) is a valid range of ranks in a sequence of count items.
+func checkRange(from, to, count int) error {
+	if from < 0 || from > to || to > count {
+		return fmt.Errorf("invalid range [%d, %d) for %d items", from, to, count)
+	}
+
+	return nil
+}
+
+// Returns the range of ranks of the i-th out of total contiguous parts of
+// a sequence of count items. Sizes of the parts differ by one at most.
+func shardRange(i, total, count int) (from, to int, err error) {
+	if total <= 0 || i < 0 || i >= total {
+		return 0, 0, fmt.Errorf("invalid shard %d of %d", i, total)
+	}
+
+	// a saturated count, parts of the sequence beyond it can't be reached
+	if count == math.MaxInt {
+		return 0, 0, fmt.Errorf("too many results to shard: %w", ErrOverflow)
+	}
+
+	size, rem := count/total, count%total
+	from = i*size + min(i, rem)
+	to = from + size
+
+	if i < rem {
+		to++
+	}
+
+	return from, to, nil
+}
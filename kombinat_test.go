@@ -6,6 +6,10 @@ package kombinat
 
 import (
 	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
 	"slices"
 	"testing"
 )
@@ -60,4 +64,432 @@ func TestBinom(t *testing.T) {
 	if a := Binom(4, 4); a != 1 {
 		t.Errorf("Binom error, want: 1, got: %v", a)
 	}
+	if a := Binom(5, 4); a != 0 {
+		t.Errorf("Binom error, want: 0, got: %v", a)
+	}
+	if a := Binom(30, 60); a != 118264581564861424 {
+		t.Errorf("Binom error, want: 118264581564861424, got: %v", a)
+	}
+}
+
+func TestMultinomial(t *testing.T) {
+	if a := Multinomial([]int{2, 1, 3, 2}); a != 1680 {
+		t.Errorf("Multinomial error, want: 1680, got: %v", a)
+	}
+	if a := Multinomial([]int{11, 11}); a != 705432 {
+		t.Errorf("Multinomial error, want: 705432, got: %v", a)
+	}
+	if a := Multinomial(nil); a != 1 {
+		t.Errorf("Multinomial error, want: 1, got: %v", a)
+	}
+}
+
+// Checks that the checked function returns the same value as the big one,
+// or ErrOverflow if the value doesn't fit in an int.
+func testChecked(t *testing.T, name string, got int, err error, want *big.Int) {
+	t.Helper()
+
+	switch {
+	case want.IsInt64() && want.Int64() <= math.MaxInt:
+		if err != nil || int64(got) != want.Int64() {
+			t.Errorf("%s error, want: %v, got: %v, %v", name, want, got, err)
+		}
+	case !errors.Is(err, ErrOverflow):
+		t.Errorf("%s error, want: ErrOverflow, got: %v, %v", name, got, err)
+	}
+}
+
+func TestCheckedAndBig(t *testing.T) {
+	for n := -1; n <= 70; n++ {
+		a, err := FacChecked(n)
+		testChecked(t, fmt.Sprintf("Fac(%d)", n), a, err, FacBig(n))
+
+		for k := -1; k <= n+1; k++ {
+			a, err := BinomChecked(k, n)
+			testChecked(t, fmt.Sprintf("Binom(%d, %d)", k, n), a, err, BinomBig(k, n))
+
+			a, err = IntPowChecked(n, k)
+			testChecked(t, fmt.Sprintf("IntPow(%d, %d)", n, k), a, err, IntPowBig(n, k))
+
+			if k >= 0 {
+				reps := []int{k, n - k + 1, k / 2}
+				a, err = MultinomialChecked(reps)
+				testChecked(t, fmt.Sprintf("Multinomial(%v)", reps), a, err, MultinomialBig(reps))
+			}
+		}
+	}
+}
+
+// Checks the sequence of a freshly initialized generator against want: iteration,
+// iteration into a destination slice after reset, iterators and seeking to every rank.
+// Ends with shard checks.
+func testGenerator[T cmp.Ordered](t *testing.T, gen SeekableGenerator[T], want [][]T) {
+	t.Helper()
+
+	for i, w := range want {
+		if !gen.Next() || slices.Compare(w, gen.Current()) != 0 {
+			t.Fatalf("Not equal at %v, \ngot: %v, \nwant: %v", i, gen.Current(), w)
+		}
+		if gen.Position() != i {
+			t.Errorf("Wrong position, got: %v, want: %v", gen.Position(), i)
+		}
+	}
+	if gen.Next() || gen.Next() {
+		t.Errorf("Didn't return false on end, dest is %v", gen.Current())
+	}
+
+	size := 0
+
+	for _, w := range want {
+		size = max(size, len(w))
+	}
+
+	dest := make([]T, size)
+
+	if err := gen.SetDest(dest); err != nil {
+		t.Errorf("%v", err)
+	}
+	if size > 0 && gen.SetDest(dest[:0:size-1]) == nil {
+		t.Errorf("Didn't err on low capacity")
+	}
+
+	gen.Reset()
+
+	for i, w := range want {
+		c := gen.Current()
+
+		if gen.Next(); slices.Compare(w, c[:len(w)]) != 0 {
+			t.Fatalf("Not equal at %v after reset, \ngot: %v, \nwant: %v", i, c, w)
+		}
+		if len(c) > 0 && &c[0] != &dest[0] {
+			t.Fatalf("Not written into dest at %v after reset", i)
+		}
+	}
+	if gen.Next() {
+		t.Errorf("Didn't return false on end after reset, dest is %v", dest)
+	}
+
+	res := make([][]T, 0, len(want))
+
+	for i, v := range all(gen.(reversibleGenerator[T]), false) {
+		if i != len(res) {
+			t.Errorf("Wrong rank, got: %v, want: %v", i, len(res))
+		}
+		res = append(res, slices.Clone(v))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal (all), \ngot: %v, \nwant: %v", res, want)
+	}
+
+	res = res[:0]
+
+	for v := range values(gen.(reversibleGenerator[T]), false) {
+		res = append(res, slices.Clone(v))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal (values), \ngot: %v, \nwant: %v", res, want)
+	}
+
+	testBatches(t, gen.(batchGenerator[T]), want)
+
+	for r := range want {
+		if err := gen.Seek(r); err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		if gen.Position() != r-1 {
+			t.Errorf("Wrong position after seek, got: %v, want: %v", gen.Position(), r-1)
+		}
+
+		for i := r; i < len(want); i++ {
+			if !gen.Next() || slices.Compare(gen.Current(), want[i]) != 0 {
+				t.Fatalf("Not equal at %v after seek to %v, \ngot: %v, \nwant: %v", i, r, gen.Current(), want[i])
+			}
+		}
+
+		if gen.Next() {
+			t.Errorf("Didn't return false on end after seek to %v", r)
+		}
+	}
+
+	if err := gen.Seek(len(want)); err != nil || gen.Next() {
+		t.Errorf("Didn't seek to end")
+	}
+	if gen.Seek(len(want)+1) == nil || gen.Seek(-1) == nil {
+		t.Errorf("Didn't err on rank out of range")
+	}
+
+	gen.Reset()
+	testShards(t, gen)
+}
+
+type batchGenerator[T any] interface {
+	Generator[T]
+	NextBatch(dst [][]T) int
+	NextBatchFlat(dst []T) int
+}
+
+// Checks NextBatch and NextBatchFlat against want, starting from the beginning.
+func testBatches[T cmp.Ordered](t *testing.T, gen batchGenerator[T], want [][]T) {
+	t.Helper()
+
+	gen.Reset()
+
+	res := make([][]T, 0, len(want))
+	batch := make([][]T, 3)
+
+	for {
+		n := gen.NextBatch(batch)
+
+		for _, v := range batch[:n] {
+			res = append(res, slices.Clone(v))
+		}
+
+		if n < len(batch) {
+			break
+		}
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal (batch), \ngot: %v, \nwant: %v", res, want)
+	}
+
+	width := 0
+
+	for _, w := range want {
+		width = max(width, len(w))
+	}
+
+	gen.Reset()
+
+	var zero T
+	flat := make([]T, 2*width+1)
+	r := 0
+
+	for n := gen.NextBatchFlat(flat); n > 0; n = gen.NextBatchFlat(flat) {
+		for i := 0; i < n; i, r = i+1, r+1 {
+			row := flat[i*width : (i+1)*width]
+
+			if r >= len(want) || slices.Compare(row[:len(want[r])], want[r]) != 0 ||
+				slices.ContainsFunc(row[len(want[r]):], func(v T) bool { return v != zero }) {
+				t.Fatalf("Not equal (flat) at %v, \ngot: %v", r, row)
+			}
+		}
+	}
+
+	if r != len(want) {
+		t.Errorf("Wrong number of results (flat), got: %v, want: %v", r, len(want))
+	}
+}
+
+// Checks that shards of the generator's sequence put together give the entire sequence.
+func testShards[T cmp.Ordered](t *testing.T, gen SeekableGenerator[T]) {
+	t.Helper()
+
+	want := make([][]T, 0)
+
+	for gen.Next() {
+		want = append(want, gen.CurrentCopy())
+	}
+
+	for total := 1; total <= len(want)+1; total++ {
+		res := make([][]T, 0, len(want))
+
+		for i := 0; i < total; i++ {
+			if err := gen.Shard(i, total); err != nil {
+				t.Fatalf("Shard(%d, %d) error'd with: %v", i, total, err)
+			}
+
+			for r, c := range all(gen.(reversibleGenerator[T]), false) {
+				if r != len(res) {
+					t.Errorf("Shard(%d, %d): wrong rank, got: %v, want: %v", i, total, r, len(res))
+				}
+
+				res = append(res, slices.Clone(c))
+			}
+
+			if gen.Next() {
+				t.Errorf("Shard(%d, %d): didn't return false on end", i, total)
+			}
+		}
+
+		if compareSliceOfSlices(res, want) != 0 {
+			t.Errorf("Not equal with %d shards, \ngot: %v, \nwant: %v", total, res, want)
+		}
+	}
+
+	if err := gen.Range(1, len(want)); err != nil {
+		t.Fatalf("Range error'd with: %v", err)
+	}
+	if gen.Seek(0) == nil {
+		t.Errorf("Didn't err on seek out of range")
+	}
+
+	gen.Reset()
+
+	if gen.Next(); gen.Position() != 1 && len(want) > 1 {
+		t.Errorf("Reset didn't preserve range, position: %v", gen.Position())
+	}
+	if gen.Range(0, len(want)+1) == nil || gen.Range(1, 0) == nil {
+		t.Errorf("Didn't err on invalid range")
+	}
+	if gen.Shard(2, 2) == nil || gen.Shard(0, 0) == nil {
+		t.Errorf("Didn't err on invalid shard")
+	}
+}
+
+func TestNextBatch(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+
+	cgen, _ := NewCombinationGenerator(2, items)
+	want, _ := Combinations(2, items)
+	testBatches[int](t, cgen, want)
+
+	pgen, _ := NewPermutationGenerator(items)
+	want, _ = Permutations(items)
+	testBatches[int](t, pgen, want)
+
+	mgen, _ := NewMultiPermutationGenerator(items[:2], []int{2, 1})
+	want, _ = MultiPermutations(items[:2], []int{2, 1})
+	testBatches[int](t, mgen, want)
+
+	vgen, _ := NewVariationGenerator(3, items)
+	want, _ = Variations(3, items)
+	testBatches[int](t, vgen, want)
+
+	vgen.Reset()
+
+	if n := vgen.NextBatchFlat(make([]int, 2)); n != 0 {
+		t.Errorf("Wrote %v variations into a too small slice", n)
+	}
+}
+
+func TestShardRange(t *testing.T) {
+	want := [][2]int{{0, 4}, {4, 7}, {7, 10}}
+
+	for i, w := range want {
+		if from, to, err := shardRange(i, 3, 10); err != nil || from != w[0] || to != w[1] {
+			t.Errorf("Wrong shard %d, got: [%d, %d) (%v), want: [%d, %d)", i, from, to, err, w[0], w[1])
+		}
+	}
+
+	if from, to, _ := shardRange(4, 5, 3); from != 3 || to != 3 {
+		t.Errorf("Wrong empty shard, got: [%d, %d)", from, to)
+	}
+}
+
+func TestOverflowingGenerators(t *testing.T) {
+	type factory func(opts ...GeneratorOption) (SeekableGenerator[int], error)
+
+	// adds opts to the ones given to f
+	wrap := func(f factory, opts ...GeneratorOption) factory {
+		return func(more ...GeneratorOption) (SeekableGenerator[int], error) {
+			return f(append(slices.Clone(opts), more...)...)
+		}
+	}
+
+	permutations := func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+		return NewPermutationIndexGenerator(21, opts...)
+	}
+	combinations := func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+		return NewCombinationIndexGenerator(50, 100, opts...)
+	}
+	variations := func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+		return NewVariationIndexGenerator(70, 2, opts...)
+	}
+	subsets := func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+		return NewSubsetIndexGenerator(0, 64, 64, opts...)
+	}
+	multiPermutations := func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+		return NewMultiPermutationIndexGenerator(slices.Repeat([]int{3}, 10), opts...)
+	}
+
+	data := map[string]factory{
+		"permutations":      permutations,
+		"permutations lex":  wrap(permutations, Lexicographic()),
+		"permutations gray": wrap(permutations, Gray()),
+		"combinations":      combinations,
+		"combinations lex":  wrap(combinations, Lexicographic()),
+		"combinations(33, 68)": func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+			return NewCombinationIndexGenerator(33, 68, opts...)
+		},
+		"variations":                variations,
+		"variations gray":           wrap(variations, Gray()),
+		"subsets":                   subsets,
+		"multiset permutations":     multiPermutations,
+		"multiset permutations lex": wrap(multiPermutations, Lexicographic()),
+		"k-permutations": func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+			return NewKPermutationIndexGenerator(20, 30, opts...)
+		},
+		"combinations with repetition": func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+			return NewCombinationWithRepetitionIndexGenerator(40, 40, opts...)
+		},
+		"multiset combinations": func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+			return NewMultiCombinationIndexGenerator(40, slices.Repeat([]int{3}, 40), opts...)
+		},
+		"product": func(opts ...GeneratorOption) (SeekableGenerator[int], error) {
+			return NewProductIndexGenerator(slices.Repeat([]int{3}, 50), opts...)
+		},
+	}
+
+	for name, f := range data {
+		t.Run(name, func(t *testing.T) {
+			gen, err := f()
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			var res [][]int
+
+			for i := 0; i < 3; i++ {
+				if !gen.Next() || gen.Position() != i {
+					t.Fatalf("Stopped at %v", i)
+				}
+
+				res = append(res, gen.CurrentCopy())
+			}
+
+			// seeking agrees with iterating, both at the beginning and at the end
+			for _, rank := range []int{1, math.MaxInt - 3} {
+				if err := gen.Seek(rank); err != nil {
+					t.Fatalf("Seek(%v) error'd with: %v", rank, err)
+				}
+
+				for i := rank; i < rank+2; i++ {
+					if !gen.Next() || gen.Position() != i {
+						t.Fatalf("Stopped at %v", i)
+					}
+
+					if i < len(res) {
+						if !slices.Equal(gen.Current(), res[i]) {
+							t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", i, gen.Current(), res[i])
+						}
+					} else {
+						res = append(res, gen.CurrentCopy())
+					}
+				}
+			}
+
+			gen.Seek(math.MaxInt - 2)
+
+			if !gen.Next() || !slices.Equal(gen.Current(), res[len(res)-1]) {
+				t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", gen.Position(), gen.Current(), res[len(res)-1])
+			}
+
+			if !gen.Next() || gen.Next() {
+				t.Errorf("Didn't stop at the end of the saturated range")
+			}
+
+			if err := gen.Shard(0, 2); !errors.Is(err, ErrOverflow) {
+				t.Errorf("Want ErrOverflow on Shard, got: %v", err)
+			}
+
+			if _, err := f(Reverse()); !errors.Is(err, ErrOverflow) {
+				t.Errorf("Want ErrOverflow with Reverse, got: %v", err)
+			}
+		})
+	}
 }
@@ -0,0 +1,597 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"iter"
+	"math/big"
+	"slices"
+)
+
+// KPermutationCount returns the number of k-permutations (variations without repetition)
+// of n elements, i.e. n!/(n-k)!.
+func KPermutationCount(k, n int) int {
+	r, _ := kPermutationCount(k, n)
+	return r
+}
+
+// KPermutationCountChecked returns the number of k-permutations of n elements,
+// or [ErrOverflow] if it doesn't fit in an int.
+func KPermutationCountChecked(k, n int) (int, error) {
+	r, ok := kPermutationCount(k, n)
+
+	if !ok {
+		return 0, ErrOverflow
+	}
+
+	return r, nil
+}
+
+// KPermutationCountBig returns the number of k-permutations of n elements as [big.Int].
+func KPermutationCountBig(k, n int) *big.Int {
+	if k < 0 || k > n {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).MulRange(int64(n-k+1), int64(n))
+}
+
+// Calculates n!/(n-k)!, reporting whether the result fits in an int.
+func kPermutationCount(k, n int) (int, bool) {
+	if k < 0 || k > n {
+		return 0, true
+	}
+
+	ret := 1
+
+	for i := n - k + 1; i <= n; i++ {
+		var ok bool
+
+		if ret, ok = mul(ret, i); !ok {
+			return ret, false
+		}
+	}
+
+	return ret, true
+}
+
+// KPermutationRank returns the rank of a k-permutation in the lexicographic order of
+// k-permutations of size len(positions) out of n elements. The k-permutation is given as
+// distinct positions of elements in the input slice.
+//
+// Returns an error if positions is empty or longer than n, or if positions are out of
+// range or not distinct.
+func KPermutationRank(n int, positions []int) (int, error) {
+	k := len(positions)
+
+	switch {
+	case k == 0:
+		return 0, fmt.Errorf("empty input slice")
+	case k > n:
+		return 0, fmt.Errorf("k is too large")
+	}
+
+	used := make([]bool, n)
+	rank := 0
+
+	for i, p := range positions {
+		if p < 0 || p >= n || used[p] {
+			return 0, fmt.Errorf("positions are out of range or not distinct")
+		}
+
+		smaller := 0
+
+		for j := 0; j < p; j++ {
+			if !used[j] {
+				smaller++
+			}
+		}
+
+		used[p] = true
+		rank += smaller * KPermutationCount(k-i-1, n-i-1)
+	}
+
+	return rank, nil
+}
+
+// KPermutationUnrank returns positions of the k-permutation at the given rank in the
+// lexicographic order of k-permutations of size k out of n elements. It's the inverse
+// of [KPermutationRank].
+//
+// Returns an error if k is less than 1 or bigger than n, or if the rank is not in
+// the range [0, KPermutationCount(k, n)).
+func KPermutationUnrank(k, n, rank int) ([]int, error) {
+	switch {
+	case k <= 0:
+		return nil, fmt.Errorf("k must be >= 1")
+	case k > n:
+		return nil, fmt.Errorf("k is too large")
+	case rank < 0 || rank >= KPermutationCount(k, n):
+		return nil, fmt.Errorf("rank out of range")
+	}
+
+	positions := make([]int, n)
+	kPermutationUnrank(k, rank, positions)
+
+	return positions[:k:k], nil
+}
+
+// Writes positions of the k-permutation at the lexicographic rank into the first
+// k elements of dest, followed by the unused positions in increasing order.
+// The length of dest is the number of elements n.
+func kPermutationUnrank(k, rank int, dest []int) {
+	n := len(dest)
+
+	for i := range dest {
+		dest[i] = i
+	}
+
+	for i := 0; i < k; i++ {
+		f := saturate(KPermutationCountChecked(k-i-1, n-i-1))
+		j := i + rank/f
+		rank %= f
+
+		// move the chosen position to i, keeping the rest in order
+		p := dest[j]
+		copy(dest[i+1:j+1], dest[i:j])
+		dest[i] = p
+	}
+}
+
+// KPermutations returns all k-permutations (variations without repetition) of size k
+// out of elements in the elems slice, in lexicographic order of positions in elems.
+// Returns an error if the input slice is empty or nil, or if k is less than 1 or bigger than len(elems).
+func KPermutations[T any](k int, elems []T) ([][]T, error) {
+	return KPermutationsWithLimit(k, elems)
+}
+
+// KPermutationsWithLimit is like [KPermutations], but returns [TooManyResultsError]
+// before allocating the results if there are more of them than allowed by opts.
+func KPermutationsWithLimit[T any](k int, elems []T, opts ...LimitOption) ([][]T, error) {
+	t, err := KPermutationsTable(k, elems, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Rows(), nil
+}
+
+// KPermutationsTable is like [KPermutationsWithLimit], but stores the results in a [Table].
+func KPermutationsTable[T any](k int, elems []T, opts ...LimitOption) (*Table[T], error) {
+	gen, err := NewKPermutationGenerator(k, elems)
+
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(elems)
+	count, err := KPermutationCountChecked(k, n)
+
+	if err := checkLimit[T](count, err, k, opts, func() *big.Int { return KPermutationCountBig(k, n) }); err != nil {
+		return nil, err
+	}
+
+	return fillTable(gen, count, k), nil
+}
+
+// KPermutationGenerator implements a [Generator] interface for generating
+// k-permutations (variations without repetition) in lexicographic order of
+// positions in the input slice.
+type KPermutationGenerator[T any] struct {
+	n, k, pos, from, to int
+	a, idx              []int
+	elems, dest         []T
+	opts                []GeneratorOption
+	first, reverse      bool
+	change              Change
+}
+
+// Init initializes a generator for k-permutations of size k out of elements in the
+// elems slice. Returns an error if the input slice is empty or nil, or if k is less
+// than 1 or bigger than len(elems).
+//
+// Supported options are [Reverse].
+func (gen *KPermutationGenerator[T]) Init(k int, elems []T, opts ...GeneratorOption) error {
+	switch {
+	case k <= 0:
+		return fmt.Errorf("k must be >= 1")
+	case len(elems) == 0:
+		return fmt.Errorf("input slice is nil or empty")
+	case k > len(elems):
+		return fmt.Errorf("k is too large")
+	}
+
+	return gen.init(k, len(elems), elems, opts)
+}
+
+// Initializes the generator for k-permutations of size k out of n elements. If elems
+// is nil, the generator is in index mode and produces positions instead of elements,
+// which requires T to be int.
+func (gen *KPermutationGenerator[T]) init(k, n int, elems []T, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if o.gray {
+		return fmt.Errorf("gray code order is not supported")
+	}
+
+	if k != gen.k {
+		gen.dest = make([]T, k)
+	}
+
+	if n != gen.n {
+		gen.a = make([]int, n)
+	}
+
+	gen.n = n
+	gen.k = k
+	gen.elems = elems
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.dest).([]int)
+	}
+
+	gen.opts = opts
+	gen.reverse = o.reverse
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(0)
+}
+
+// Returns the number of k-permutations in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *KPermutationGenerator[T]) count() int {
+	return saturate(KPermutationCountChecked(gen.k, gen.n))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
+func (gen *KPermutationGenerator[T]) Reset() {
+	s, from, to := gen.dest, gen.from, gen.to
+	gen.init(gen.k, gen.n, gen.elems, gen.opts)
+	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
+}
+
+// Current returns the internal slice that holds the current k-permutation.
+// If you need to modify the returned slice, use [KPermutationGenerator.CurrentCopy] instead.
+func (gen *KPermutationGenerator[T]) Current() []T {
+	return gen.dest
+}
+
+// CurrentCopy returns a copy of the internal slice that holds the current k-permutation.
+// If you don't need to modify the returned slice, use [KPermutationGenerator.Current] to avoid allocation.
+func (gen *KPermutationGenerator[T]) CurrentCopy() []T {
+	return slices.Clone(gen.dest)
+}
+
+// SetDest sets a destination slice that will receive the results.
+// Returns an error if there's not enough capacity in the slice.
+//
+// After the destination slice is set, subsequent calls to [KPermutationGenerator.Current]
+// will return the provided slice.
+func (gen *KPermutationGenerator[T]) SetDest(dest []T) error {
+	if got := cap(dest); got < gen.k {
+		return fmt.Errorf(capacityMsg(gen.k, got))
+	}
+
+	copy(dest, gen.dest)
+	gen.dest = dest
+
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
+	return nil
+}
+
+// Next produces a new k-permutation in the generator. If it returns false,
+// there are no more k-permutations available.
+func (gen *KPermutationGenerator[T]) Next() bool {
+	if gen.k <= 0 || gen.pos+1 >= gen.to {
+		return false
+	}
+
+	a, k := gen.a, gen.k
+
+	switch {
+	case gen.first:
+		gen.first = false
+		gen.change = Change{Kind: ChangeAll}
+	case k < gen.n && a[k-1] < a[gen.n-1]:
+		// the unused positions after k are in increasing order, so the last element is
+		// replaced by the smallest bigger unused one, which leaves them in order
+		j, _ := slices.BinarySearch(a[k:], a[k-1])
+		j += k
+		gen.change = rangeChange(k-1, k, a[k-1], a[j])
+		a[k-1], a[j] = a[j], a[k-1]
+		gen.fill(k - 1)
+	default:
+		// the last element is bigger than the unused ones, so after reversing them,
+		// the next permutation of all positions changes an element before it and
+		// skips the arrangements of the unused ones
+		slices.Reverse(a[k:])
+
+		i := gen.n - 2
+
+		for a[i] > a[i+1] {
+			i--
+		}
+
+		j := gen.n - 1
+
+		for a[j] < a[i] {
+			j--
+		}
+
+		gen.change = rangeChange(i, k, a[i], a[j])
+		a[i], a[j] = a[j], a[i]
+		slices.Reverse(a[i+1:])
+		gen.fill(i)
+	}
+
+	gen.pos++
+
+	return true
+}
+
+// Prev produces the k-permutation before the current one in the sequence of the generator.
+// If it returns false, there are no previous k-permutations available in the range.
+// It reverses the step of [KPermutationGenerator.Next], except right after [KPermutationGenerator.Seek]
+// or at the end of the range, when it seeks to the previous k-permutation instead.
+func (gen *KPermutationGenerator[T]) Prev() bool {
+	if gen.change.Kind == ChangeNone {
+		return prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	if gen.pos-1 < gen.from {
+		return false
+	}
+
+	a, k := gen.a, gen.k
+
+	if k < gen.n && a[k] < a[k-1] {
+		// the last element is replaced by the biggest smaller unused one
+		j, _ := slices.BinarySearch(a[k:], a[k-1])
+		j += k - 1
+		gen.change = rangeChange(k-1, k, a[k-1], a[j])
+		a[k-1], a[j] = a[j], a[k-1]
+		gen.fill(k - 1)
+		gen.pos--
+
+		return true
+	}
+
+	// the last element is smaller than the unused ones, so the previous permutation
+	// of all positions changes an element before it, and ends with the unused ones
+	// in decreasing order, which reversing them restores to the increasing order
+	i := gen.n - 2
+
+	for a[i] < a[i+1] {
+		i--
+	}
+
+	j := gen.n - 1
+
+	for a[j] > a[i] {
+		j--
+	}
+
+	gen.change = rangeChange(i, k, a[i], a[j])
+	a[i], a[j] = a[j], a[i]
+	slices.Reverse(a[i+1:])
+	slices.Reverse(a[k:])
+	gen.fill(i)
+	gen.pos--
+
+	return true
+}
+
+// LastChange returns the change of the current k-permutation made by the last call to
+// [KPermutationGenerator.Next] or [KPermutationGenerator.Prev]. Every k-permutation after the first
+// one differs from the previous one in the last element ([ChangeReplace]),
+// or in elements from some position to the end ([ChangeRange]).
+func (gen *KPermutationGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current k-permutation in the sequence of the generator,
+// or -1 if [KPermutationGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [KPermutationGenerator.Prev] is called.
+func (gen *KPermutationGenerator[T]) Position() int {
+	return gen.pos
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [KPermutationGenerator.Next] produces the k-permutation
+// at that rank, and [KPermutationGenerator.Prev]
+// the one before it. Seeking to the end of the range set by [KPermutationGenerator.Range]
+// (KPermutationCount(k, n) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *KPermutationGenerator[T]) Seek(rank int) error {
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.first = rank < gen.count()
+	gen.pos = rank - 1
+
+	if !gen.first {
+		rank--
+	}
+
+	kPermutationUnrank(gen.k, rank, gen.a)
+	gen.fill(0)
+
+	return nil
+}
+
+// Writes elements at the first k positions in a into the current k-permutation,
+// starting from the position i.
+func (gen *KPermutationGenerator[T]) fill(i int) {
+	if gen.idx != nil {
+		copy(gen.idx[i:], gen.a[i:gen.k])
+		return
+	}
+
+	for ; i < gen.k; i++ {
+		gen.dest[i] = gen.elems[gen.a[i]]
+	}
+}
+
+// Range limits the generator to the k-permutations with ranks in the range [from, to)
+// and moves it to the rank from. [KPermutationGenerator.Reset] preserves the range, and
+// [KPermutationGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, KPermutationCount(k, n)].
+func (gen *KPermutationGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *KPermutationGenerator[T]) end() {
+	gen.pos = gen.to
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *KPermutationGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// All returns an iterator over all k-permutations paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [KPermutationGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded k-permutation and can be resumed with [KPermutationGenerator.Next] or restarted
+// with [KPermutationGenerator.Reset].
+func (gen *KPermutationGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [KPermutationGenerator.All], but it yields only the k-permutations.
+func (gen *KPermutationGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following k-permutations, as if by calling
+// [KPermutationGenerator.Next] and [KPermutationGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of k-permutations written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *KPermutationGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [KPermutationGenerator.NextBatch], but writes the k-permutations one after another
+// into dst, where every k-permutation takes k elements. Writes as many k-permutations as fit into dst.
+func (gen *KPermutationGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.k)
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current k-permutation, and shares only the input with the original.
+func (gen *KPermutationGenerator[T]) Clone() *KPermutationGenerator[T] {
+	c := *gen
+	c.dest = slices.Clone(gen.dest)
+	c.a = slices.Clone(gen.a)
+
+	if gen.idx != nil {
+		c.idx = any(c.dest).([]int)
+	}
+
+	return &c
+}
+
+// NewKPermutationGenerator creates and initializes a new KPermutationGenerator.
+// Arguments and returned errors are the same ones from the [KPermutationGenerator.Init] method.
+func NewKPermutationGenerator[T any](k int, elems []T, opts ...GeneratorOption) (*KPermutationGenerator[T], error) {
+	gen := new(KPermutationGenerator[T])
+	err := gen.Init(k, elems, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// NewKPermutationIndexGenerator creates a new KPermutationGenerator that produces k-permutations
+// of size k as positions of elements in an implicit input of size n, i.e. as integers in the
+// range [0, n), without requiring the input slice. Returns an error if k < 1, n < 1 or k > n,
+// or if the options can't be applied, see [KPermutationGenerator.Init].
+func NewKPermutationIndexGenerator(k, n int, opts ...GeneratorOption) (*KPermutationGenerator[int], error) {
+	switch {
+	case k <= 0:
+		return nil, fmt.Errorf("k must be >= 1")
+	case n <= 0:
+		return nil, fmt.Errorf("n must be >= 1")
+	case k > n:
+		return nil, fmt.Errorf("k is too large")
+	}
+
+	gen := new(KPermutationGenerator[int])
+
+	if err := gen.init(k, n, nil, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// KPermutationsSeq returns an iterator over k-permutations of size k out of elements
+// in the elems slice, paired with their rank. The sequence is empty if the arguments
+// are invalid, see [KPermutationGenerator.Init] for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func KPermutationsSeq[T any](k int, elems []T) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewKPermutationGenerator(k, elems)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
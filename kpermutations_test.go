@@ -0,0 +1,294 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+var (
+	_kperm_data = []struct {
+		input []int
+		want  [][]int
+		k     int
+	}{
+		{
+			input: []int{1, 2, 3},
+			want:  [][]int{{1}, {2}, {3}},
+			k:     1,
+		},
+		{
+			input: []int{1},
+			want:  [][]int{{1}},
+			k:     1,
+		},
+		{
+			input: []int{1, 2, 3},
+			want: [][]int{
+				{1, 2},
+				{1, 3},
+				{2, 1},
+				{2, 3},
+				{3, 1},
+				{3, 2},
+			},
+			k: 2,
+		},
+		{
+			input: []int{1, 2, 3},
+			want: [][]int{
+				{1, 2, 3},
+				{1, 3, 2},
+				{2, 1, 3},
+				{2, 3, 1},
+				{3, 1, 2},
+				{3, 2, 1},
+			},
+			k: 3,
+		},
+		{
+			input: []int{1, 2, 3, 4},
+			want: [][]int{
+				{1, 2},
+				{1, 3},
+				{1, 4},
+				{2, 1},
+				{2, 3},
+				{2, 4},
+				{3, 1},
+				{3, 2},
+				{3, 4},
+				{4, 1},
+				{4, 2},
+				{4, 3},
+			},
+			k: 2,
+		},
+	}
+)
+
+// Returns k-permutations of items by filtering variations with repeated elements.
+func kPermutationsFromVariations(k int, items []int) [][]int {
+	vars, _ := Variations(k, items)
+
+	return slices.DeleteFunc(vars, func(v []int) bool {
+		s := slices.Clone(v)
+		slices.Sort(s)
+		return len(slices.Compact(s)) != len(v)
+	})
+}
+
+func TestKPermutations(t *testing.T) {
+	for _, d := range _kperm_data {
+		d := d
+
+		t.Run(fmt.Sprintf("kp(%d,%d)", d.k, len(d.input)), func(t *testing.T) {
+			res, err := KPermutations(d.k, d.input)
+
+			if err != nil {
+				t.Errorf("Error'd with: %v", err)
+			}
+
+			if compareSliceOfSlices(res, d.want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, d.want)
+			}
+		})
+	}
+
+	items := []int{1, 2, 3, 4, 5, 6}
+
+	for k := 1; k <= len(items); k++ {
+		res, _ := KPermutations(k, items)
+
+		if want := kPermutationsFromVariations(k, items); compareSliceOfSlices(res, want) != 0 {
+			t.Errorf("Not equal for k = %d, \ngot: %v, \nwant: %v", k, res, want)
+		}
+	}
+
+	if _, err := KPermutations(0, []int{1}); err == nil {
+		t.Errorf("Didn't err on k = 0")
+	}
+	if _, err := KPermutations(2, []int{1}); err == nil {
+		t.Errorf("Didn't err on k > n")
+	}
+	if _, err := KPermutations(1, []int{}); err == nil {
+		t.Errorf("Didn't err on empty input")
+	}
+}
+
+func TestKPermutationCount(t *testing.T) {
+	if n := KPermutationCount(3, 5); n != 60 {
+		t.Errorf("Want 60, got %v", n)
+	}
+	if n := KPermutationCount(6, 5); n != 0 {
+		t.Errorf("Want 0, got %v", n)
+	}
+	if n := KPermutationCount(5, 5); n != PermutationCount(5) {
+		t.Errorf("Want %v, got %v", PermutationCount(5), n)
+	}
+	if _, err := KPermutationCountChecked(20, 40); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Want ErrOverflow, got %v", err)
+	}
+	if n := KPermutationCountBig(3, 5).Int64(); n != 60 {
+		t.Errorf("Want 60, got %v", n)
+	}
+}
+
+func TestKPermutationRank(t *testing.T) {
+	for _, kd := range _kperm_data {
+		kd := kd
+		n := len(kd.input)
+
+		t.Run(fmt.Sprintf("kp(%d,%d)", kd.k, n), func(t *testing.T) {
+			for r, w := range kd.want {
+				positions, err := KPermutationUnrank(kd.k, n, r)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				for i, p := range positions {
+					if kd.input[p] != w[i] {
+						t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", r, positions, w)
+						break
+					}
+				}
+
+				if rank, err := KPermutationRank(n, positions); err != nil || rank != r {
+					t.Errorf("Wrong rank for %v, got: %v (%v), want: %v", positions, rank, err, r)
+				}
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := KPermutationUnrank(2, 3, 6); err == nil {
+			t.Errorf("Didn't err on rank out of range")
+		}
+		if _, err := KPermutationUnrank(4, 3, 0); err == nil {
+			t.Errorf("Didn't err on k > n")
+		}
+		if _, err := KPermutationRank(3, []int{1, 1}); err == nil {
+			t.Errorf("Didn't err on repeated positions")
+		}
+		if _, err := KPermutationRank(3, []int{0, 3}); err == nil {
+			t.Errorf("Didn't err on position out of range")
+		}
+		if _, err := KPermutationRank(3, nil); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+	})
+}
+
+func TestKPermutationGenerator(t *testing.T) {
+	for _, kd := range _kperm_data {
+		kd := kd
+
+		t.Run(fmt.Sprintf("kp(%d,%d)", kd.k, len(kd.input)), func(t *testing.T) {
+			gen, err := NewKPermutationGenerator(kd.k, kd.input)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			testGenerator[int](t, gen, kd.want)
+		})
+	}
+
+	items := []int{1, 2, 3, 4, 5}
+
+	for k := 1; k <= len(items); k++ {
+		gen, _ := NewKPermutationGenerator(k, items)
+		testGenerator[int](t, gen, kPermutationsFromVariations(k, items))
+	}
+}
+
+func TestKPermutationIndexGenerator(t *testing.T) {
+	for n := 1; n <= 4; n++ {
+		for k := 1; k <= n; k++ {
+			gen, err := NewKPermutationIndexGenerator(k, n)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			want, _ := KPermutations(k, indices(n))
+			testGenerator[int](t, gen, want)
+		}
+	}
+
+	gen, _ := NewKPermutationIndexGenerator(2, 4, Reverse())
+	want, _ := KPermutations(2, indices(4))
+
+	if !gen.Prev() || !slices.Equal(gen.Current(), want[len(want)-1]) {
+		t.Errorf("Not at the end with Reverse, got: %v, want: %v", gen.Current(), want[len(want)-1])
+	}
+
+	if _, err := NewKPermutationIndexGenerator(1, 0); err == nil {
+		t.Errorf("Didn't err on n = 0")
+	}
+}
+
+func TestKPermutationsSeq(t *testing.T) {
+	for _, kd := range _kperm_data {
+		res := make([][]int, 0, len(kd.want))
+
+		for _, v := range KPermutationsSeq(kd.k, kd.input) {
+			res = append(res, slices.Clone(v))
+		}
+
+		if compareSliceOfSlices(res, kd.want) != 0 {
+			t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, kd.want)
+		}
+	}
+
+	for range KPermutationsSeq(3, []int{1, 2}) {
+		t.Errorf("Didn't return an empty sequence on invalid input")
+	}
+}
+
+func BenchmarkKPermutationGenerator(b *testing.B) {
+	items := []int{1, 2, 3, 4, 5, 6}
+
+	for k := 2; k <= 6; k++ {
+		k := k
+		gen := new(KPermutationGenerator[int])
+
+		b.Run(fmt.Sprintf("kp(%d,6)=%d", k, KPermutationCount(k, 6)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				gen.Init(k, items)
+				for gen.Next() {
+					gen.Current()
+				}
+			}
+		})
+	}
+}
+
+// Generates the same k-permutations, in a different order, by permuting every combination.
+func BenchmarkKPermutationNested(b *testing.B) {
+	items := []int{1, 2, 3, 4, 5, 6}
+
+	for k := 2; k <= 6; k++ {
+		k := k
+		comb := new(CombinationGenerator[int])
+		perm := new(PermutationGenerator[int])
+
+		b.Run(fmt.Sprintf("kp(%d,6)=%d", k, KPermutationCount(k, 6)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				comb.Init(k, items)
+				for comb.Next() {
+					perm.Init(comb.Current())
+					for perm.Next() {
+						perm.Current()
+					}
+				}
+			}
+		})
+	}
+}
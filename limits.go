@@ -0,0 +1,82 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"unsafe"
+)
+
+// ErrTooManyResults is matched by [TooManyResultsError] with [errors.Is].
+var ErrTooManyResults = errors.New("too many results")
+
+// TooManyResultsError is returned by whole-result functions when the result set
+// is larger than the limit set with [LimitOption]s, or when its size doesn't fit in an int.
+type TooManyResultsError struct {
+	Count *big.Int // size of the result set
+	Limit int      // maximum number of results allowed by the options
+}
+
+func (e *TooManyResultsError) Error() string {
+	return fmt.Sprintf("too many results: %s, limit is %d", e.Count, e.Limit)
+}
+
+// Is reports whether target is [ErrTooManyResults].
+func (e *TooManyResultsError) Is(target error) bool {
+	return target == ErrTooManyResults
+}
+
+// LimitOption sets a limit on the size of the result set of a whole-result function.
+type LimitOption func(*limits)
+
+type limits struct {
+	maxResults int
+	maxBytes   int
+}
+
+// MaxResults limits the number of results to n.
+func MaxResults(n int) LimitOption {
+	return func(l *limits) {
+		l.maxResults = n
+	}
+}
+
+// MaxBytes limits the estimated memory used by the results to n bytes. The estimate
+// includes the elements and the header of every result slice, but not the memory
+// referenced by the elements themselves.
+func MaxBytes(n int) LimitOption {
+	return func(l *limits) {
+		l.maxBytes = n
+	}
+}
+
+// Returns the maximum number of results of the given width allowed by the options.
+func resultLimit[T any](width int, opts []LimitOption) int {
+	l := limits{maxResults: math.MaxInt, maxBytes: math.MaxInt}
+
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	var zero T
+	size := width*int(unsafe.Sizeof(zero)) + int(unsafe.Sizeof([]T(nil)))
+
+	return max(min(l.maxResults, l.maxBytes/size), 0)
+}
+
+// Checks the result of a checked counting function against the limit of results
+// of the given width. countBig is called to report the size of the result set.
+func checkLimit[T any](count int, err error, width int, opts []LimitOption, countBig func() *big.Int) error {
+	limit := resultLimit[T](width, opts)
+
+	if err != nil || count > limit {
+		return &TooManyResultsError{Count: countBig(), Limit: limit}
+	}
+
+	return nil
+}
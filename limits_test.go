@@ -0,0 +1,86 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTooManyResultsError(t *testing.T) {
+	_, err := PermutationsWithLimit([]int{1, 2, 3, 4}, MaxResults(10))
+
+	if !errors.Is(err, ErrTooManyResults) {
+		t.Fatalf("Want ErrTooManyResults, got %v", err)
+	}
+
+	var tmr *TooManyResultsError
+
+	if !errors.As(err, &tmr) {
+		t.Fatalf("Want TooManyResultsError, got %T", err)
+	}
+
+	if tmr.Count.Int64() != 24 || tmr.Limit != 10 {
+		t.Errorf("Want count 24 and limit 10, got %v and %v", tmr.Count, tmr.Limit)
+	}
+
+	if s := err.Error(); s != "too many results: 24, limit is 10" {
+		t.Errorf("Wrong message: %v", s)
+	}
+}
+
+func TestWithLimit(t *testing.T) {
+	items := make([]int, 30)
+	reps := make([]int, 30)
+
+	for i := range items {
+		items[i] = i
+		reps[i] = 2
+	}
+
+	cases := []struct {
+		name string
+		fn   func(opts ...LimitOption) ([][]int, error)
+		want int
+	}{
+		{"Combinations", func(opts ...LimitOption) ([][]int, error) { return CombinationsWithLimit(2, items[:5], opts...) }, 10},
+		{"Permutations", func(opts ...LimitOption) ([][]int, error) { return PermutationsWithLimit(items[:4], opts...) }, 24},
+		{"MultiPermutations", func(opts ...LimitOption) ([][]int, error) {
+			return MultiPermutationsWithLimit(items[:2], reps[:2], opts...)
+		}, 6},
+		{"Variations", func(opts ...LimitOption) ([][]int, error) { return VariationsWithLimit(2, items[:4], opts...) }, 16},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if res, err := c.fn(); err != nil || len(res) != c.want {
+				t.Errorf("Want %d results, got %d, %v", c.want, len(res), err)
+			}
+			if res, err := c.fn(MaxResults(c.want)); err != nil || len(res) != c.want {
+				t.Errorf("Want %d results, got %d, %v", c.want, len(res), err)
+			}
+			if _, err := c.fn(MaxResults(c.want - 1)); !errors.Is(err, ErrTooManyResults) {
+				t.Errorf("Want ErrTooManyResults, got %v", err)
+			}
+			if _, err := c.fn(MaxBytes(100)); !errors.Is(err, ErrTooManyResults) {
+				t.Errorf("Want ErrTooManyResults, got %v", err)
+			}
+		})
+	}
+
+	// counts that overflow or can't be allocated
+	if _, err := Permutations(items[:21]); !errors.Is(err, ErrTooManyResults) {
+		t.Errorf("Want ErrTooManyResults, got %v", err)
+	}
+	if _, err := Permutations(items[:20]); !errors.Is(err, ErrTooManyResults) {
+		t.Errorf("Want ErrTooManyResults, got %v", err)
+	}
+	if _, err := MultiPermutations(items, reps); !errors.Is(err, ErrTooManyResults) {
+		t.Errorf("Want ErrTooManyResults, got %v", err)
+	}
+	if _, err := Variations(30, items); !errors.Is(err, ErrTooManyResults) {
+		t.Errorf("Want ErrTooManyResults, got %v", err)
+	}
+}
@@ -0,0 +1,521 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"iter"
+	"math/big"
+	"slices"
+)
+
+// CombinationWithRepetitionCount returns the number of combinations with repetition
+// of size k out of n elements, i.e. the number of multisets of size k.
+func CombinationWithRepetitionCount(k, n int) int {
+	return Binom(k, n+k-1)
+}
+
+// CombinationWithRepetitionCountChecked returns the number of combinations with repetition
+// of size k out of n elements, or [ErrOverflow] if it doesn't fit in an int.
+func CombinationWithRepetitionCountChecked(k, n int) (int, error) {
+	return BinomChecked(k, n+k-1)
+}
+
+// CombinationWithRepetitionCountBig returns the number of combinations with repetition
+// of size k out of n elements as [big.Int].
+func CombinationWithRepetitionCountBig(k, n int) *big.Int {
+	return BinomBig(k, n+k-1)
+}
+
+// CombinationWithRepetitionRank returns the rank of a combination with repetition in
+// the lexicographic order of combinations with repetition of size len(positions) out of
+// n elements. The combination is given as non-decreasing positions of the chosen elements
+// in the input slice.
+//
+// Returns an error if positions is empty, or if positions are out of range or decreasing.
+func CombinationWithRepetitionRank(n int, positions []int) (int, error) {
+	k := len(positions)
+
+	if k == 0 {
+		return 0, fmt.Errorf("empty input slice")
+	}
+
+	rank := 0
+	next := 0
+
+	for i, p := range positions {
+		if p < next || p >= n {
+			return 0, fmt.Errorf("positions are out of range or decreasing")
+		}
+
+		for ; next < p; next++ {
+			rank += CombinationWithRepetitionCount(k-i-1, n-next)
+		}
+	}
+
+	return rank, nil
+}
+
+// CombinationWithRepetitionUnrank returns non-decreasing positions of the combination
+// with repetition at the given rank in the lexicographic order of combinations with
+// repetition of size k out of n elements. It's the inverse of [CombinationWithRepetitionRank].
+//
+// Returns an error if k < 1, n < 1, or if the rank is not in the range
+// [0, CombinationWithRepetitionCount(k, n)).
+func CombinationWithRepetitionUnrank(k, n, rank int) ([]int, error) {
+	switch {
+	case k <= 0:
+		return nil, fmt.Errorf("k must be >= 1")
+	case n <= 0:
+		return nil, fmt.Errorf("n must be >= 1")
+	case rank < 0 || rank >= CombinationWithRepetitionCount(k, n):
+		return nil, fmt.Errorf("rank out of range")
+	}
+
+	positions := make([]int, k)
+	combinationWithRepetitionUnrank(n, rank, positions)
+
+	return positions, nil
+}
+
+// Writes positions of the combination with repetition at the lexicographic rank into dest.
+func combinationWithRepetitionUnrank(n, rank int, dest []int) {
+	k := len(dest)
+	p := 0
+
+	for i := 0; i < k; i++ {
+		for {
+			c := saturate(CombinationWithRepetitionCountChecked(k-i-1, n-p))
+
+			if rank < c {
+				break
+			}
+
+			rank -= c
+			p++
+		}
+
+		dest[i] = p
+	}
+}
+
+// CombinationsWithRepetition returns all combinations with repetition of size k out of
+// elements in the elems slice, in lexicographic order of positions in elems.
+// Returns an error when k <= 0 or when elems is nil or empty.
+func CombinationsWithRepetition[T any](k int, elems []T) ([][]T, error) {
+	return CombinationsWithRepetitionWithLimit(k, elems)
+}
+
+// CombinationsWithRepetitionWithLimit is like [CombinationsWithRepetition], but returns
+// [TooManyResultsError] before allocating the results if there are more of them than allowed by opts.
+func CombinationsWithRepetitionWithLimit[T any](k int, elems []T, opts ...LimitOption) ([][]T, error) {
+	t, err := CombinationsWithRepetitionTable(k, elems, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Rows(), nil
+}
+
+// CombinationsWithRepetitionTable is like [CombinationsWithRepetitionWithLimit], but stores
+// the results in a [Table].
+func CombinationsWithRepetitionTable[T any](k int, elems []T, opts ...LimitOption) (*Table[T], error) {
+	gen, err := NewCombinationWithRepetitionGenerator(k, elems)
+
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(elems)
+	count, err := CombinationWithRepetitionCountChecked(k, n)
+
+	if err := checkLimit[T](count, err, k, opts, func() *big.Int { return CombinationWithRepetitionCountBig(k, n) }); err != nil {
+		return nil, err
+	}
+
+	return fillTable(gen, count, k), nil
+}
+
+// CombinationWithRepetitionGenerator implements a [Generator] interface
+// for generating combinations with repetition in lexicographic order of
+// positions in the input slice.
+type CombinationWithRepetitionGenerator[T any] struct {
+	n, k, pos, from, to int
+	p, idx              []int
+	elems, dest         []T
+	opts                []GeneratorOption
+	first, reverse      bool
+	change              Change
+}
+
+// Init initializes a generator for combinations with repetition of size k out of
+// elements in the elems slice. Returns an error if input slice is nil or empty,
+// or if k < 1. Unlike in combinations without repetition, k can be bigger than len(elems).
+//
+// Supported options are [Reverse].
+func (gen *CombinationWithRepetitionGenerator[T]) Init(k int, elems []T, opts ...GeneratorOption) error {
+	if k <= 0 {
+		return fmt.Errorf("k must be >= 1")
+	}
+
+	if len(elems) == 0 {
+		return fmt.Errorf("input slice is nil or empty")
+	}
+
+	return gen.init(k, len(elems), elems, opts)
+}
+
+// Initializes the generator for combinations of size k out of n elements. If elems
+// is nil, the generator is in index mode and produces positions instead of elements,
+// which requires T to be int.
+func (gen *CombinationWithRepetitionGenerator[T]) init(k, n int, elems []T, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if o.gray {
+		return fmt.Errorf("gray code order is not supported")
+	}
+
+	if k != gen.k {
+		gen.dest = make([]T, k)
+		gen.p = make([]int, k)
+	}
+
+	gen.n = n
+	gen.k = k
+	gen.elems = elems
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.dest).([]int)
+	}
+
+	gen.opts = opts
+	gen.reverse = o.reverse
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(0)
+}
+
+// Returns the number of combinations in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *CombinationWithRepetitionGenerator[T]) count() int {
+	return saturate(CombinationWithRepetitionCountChecked(gen.k, gen.n))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
+func (gen *CombinationWithRepetitionGenerator[T]) Reset() {
+	s, from, to := gen.dest, gen.from, gen.to
+	gen.init(gen.k, gen.n, gen.elems, gen.opts)
+	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
+}
+
+// Current returns the internal slice that holds the current combination.
+// If you need to modify the returned slice, use [CombinationWithRepetitionGenerator.CurrentCopy] instead.
+func (gen *CombinationWithRepetitionGenerator[T]) Current() []T {
+	return gen.dest
+}
+
+// CurrentCopy returns a copy of the internal slice that holds the current combination.
+// If you don't need to modify the returned slice, use [CombinationWithRepetitionGenerator.Current] to avoid allocation.
+func (gen *CombinationWithRepetitionGenerator[T]) CurrentCopy() []T {
+	return slices.Clone(gen.dest)
+}
+
+// SetDest sets a destination slice that will receive the results.
+// Returns an error if there's not enough capacity in the slice.
+//
+// After the destination slice is set, subsequent calls to [CombinationWithRepetitionGenerator.Current]
+// will return the provided slice.
+func (gen *CombinationWithRepetitionGenerator[T]) SetDest(dest []T) error {
+	if got := cap(dest); got < gen.k {
+		return fmt.Errorf(capacityMsg(gen.k, got))
+	}
+
+	copy(dest, gen.dest)
+	gen.dest = dest
+
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
+	return nil
+}
+
+// Next produces a new combination in the generator. If it returns false,
+// there are no more combinations available.
+func (gen *CombinationWithRepetitionGenerator[T]) Next() bool {
+	if gen.k <= 0 || gen.pos+1 >= gen.to {
+		return false
+	}
+
+	if gen.first {
+		gen.first = false
+		gen.change = Change{Kind: ChangeAll}
+	} else {
+		// the rightmost position that can be incremented, and all after it
+		// get the same value
+		i := gen.k - 1
+
+		for gen.p[i] == gen.n-1 {
+			i--
+		}
+
+		v := gen.p[i] + 1
+		gen.change = rangeChange(i, gen.k, gen.p[i], v)
+
+		for j := i; j < gen.k; j++ {
+			gen.p[j] = v
+		}
+
+		gen.fill(i)
+	}
+
+	gen.pos++
+
+	return true
+}
+
+// Prev produces the combination before the current one in the sequence of the generator.
+// If it returns false, there are no previous combinations available in the range.
+// It steps back in the lexicographic order, except right after [CombinationWithRepetitionGenerator.Seek]
+// or at the end of the range, when it seeks to the previous combination instead.
+func (gen *CombinationWithRepetitionGenerator[T]) Prev() bool {
+	if gen.change.Kind == ChangeNone {
+		return prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	if gen.pos-1 < gen.from {
+		return false
+	}
+
+	// the first position of the last run of equal values, which gets decremented,
+	// and all after it get the biggest value
+	i := gen.k - 1
+
+	for i > 0 && gen.p[i-1] == gen.p[i] {
+		i--
+	}
+
+	gen.change = rangeChange(i, gen.k, gen.p[i], gen.p[i]-1)
+	gen.p[i]--
+
+	for j := i + 1; j < gen.k; j++ {
+		gen.p[j] = gen.n - 1
+	}
+
+	gen.fill(i)
+	gen.pos--
+
+	return true
+}
+
+// LastChange returns the change of the current combination made by the last call to
+// [CombinationWithRepetitionGenerator.Next] or [CombinationWithRepetitionGenerator.Prev].
+// Every combination after the first
+// one differs from the previous one in the last element ([ChangeReplace]),
+// or in elements from some position to the end ([ChangeRange]).
+func (gen *CombinationWithRepetitionGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current combination in the sequence of the generator,
+// or -1 if [CombinationWithRepetitionGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [CombinationWithRepetitionGenerator.Prev] is called.
+func (gen *CombinationWithRepetitionGenerator[T]) Position() int {
+	return gen.pos
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [CombinationWithRepetitionGenerator.Next] produces the
+// combination at that rank, and [CombinationWithRepetitionGenerator.Prev]
+// the one before it. Seeking to the end of the range set by
+// [CombinationWithRepetitionGenerator.Range] (CombinationWithRepetitionCount(k, n) by default)
+// moves the generator to the end. Returns an error if the rank is out of range.
+func (gen *CombinationWithRepetitionGenerator[T]) Seek(rank int) error {
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.first = rank < gen.count()
+	gen.pos = rank - 1
+
+	if !gen.first {
+		rank--
+	}
+
+	combinationWithRepetitionUnrank(gen.n, rank, gen.p)
+	gen.fill(0)
+
+	return nil
+}
+
+// Fills dest from the position i to the end with elements at positions in p,
+// or with the positions themselves in index mode.
+func (gen *CombinationWithRepetitionGenerator[T]) fill(i int) {
+	if gen.idx != nil {
+		copy(gen.idx[i:], gen.p[i:])
+		return
+	}
+
+	for ; i < gen.k; i++ {
+		gen.dest[i] = gen.elems[gen.p[i]]
+	}
+}
+
+// Range limits the generator to the combinations with ranks in the range [from, to)
+// and moves it to the rank from. [CombinationWithRepetitionGenerator.Reset] preserves the range,
+// and [CombinationWithRepetitionGenerator.Seek] is allowed only within it. Returns an error if
+// the range is invalid, i.e. if it's not within [0, CombinationWithRepetitionCount(k, n)].
+func (gen *CombinationWithRepetitionGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *CombinationWithRepetitionGenerator[T]) end() {
+	gen.pos = gen.to
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *CombinationWithRepetitionGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// All returns an iterator over all combinations paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [CombinationWithRepetitionGenerator.Current]. If the loop is stopped early, the generator
+// stays on the last yielded combination and can be resumed with
+// [CombinationWithRepetitionGenerator.Next] or restarted with [CombinationWithRepetitionGenerator.Reset].
+func (gen *CombinationWithRepetitionGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [CombinationWithRepetitionGenerator.All], but it yields only the combinations.
+func (gen *CombinationWithRepetitionGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following combinations, as if by calling
+// [CombinationWithRepetitionGenerator.Next] and [CombinationWithRepetitionGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of combinations written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *CombinationWithRepetitionGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [CombinationWithRepetitionGenerator.NextBatch], but writes the combinations one after another
+// into dst, where every combination takes k elements. Writes as many combinations as fit into dst.
+func (gen *CombinationWithRepetitionGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.k)
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current combination, and shares only the input with the original.
+func (gen *CombinationWithRepetitionGenerator[T]) Clone() *CombinationWithRepetitionGenerator[T] {
+	c := *gen
+	c.dest = slices.Clone(gen.dest)
+	c.p = slices.Clone(gen.p)
+
+	if gen.idx != nil {
+		c.idx = any(c.dest).([]int)
+	}
+
+	return &c
+}
+
+// NewCombinationWithRepetitionGenerator creates and initializes a new CombinationWithRepetitionGenerator.
+// Arguments and returned errors are the same ones from the [CombinationWithRepetitionGenerator.Init] method.
+func NewCombinationWithRepetitionGenerator[T any](k int, elems []T, opts ...GeneratorOption) (*CombinationWithRepetitionGenerator[T], error) {
+	gen := new(CombinationWithRepetitionGenerator[T])
+	err := gen.Init(k, elems, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// NewCombinationWithRepetitionIndexGenerator creates a new CombinationWithRepetitionGenerator
+// that produces combinations with repetition of size k as positions of elements in an
+// implicit input of size n, i.e. as integers in the range [0, n), without requiring the
+// input slice. Returns an error if k < 1 or n < 1, or if the options can't be applied,
+// see [CombinationWithRepetitionGenerator.Init].
+func NewCombinationWithRepetitionIndexGenerator(k, n int, opts ...GeneratorOption) (*CombinationWithRepetitionGenerator[int], error) {
+	switch {
+	case k <= 0:
+		return nil, fmt.Errorf("k must be >= 1")
+	case n <= 0:
+		return nil, fmt.Errorf("n must be >= 1")
+	}
+
+	gen := new(CombinationWithRepetitionGenerator[int])
+
+	if err := gen.init(k, n, nil, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// CombinationsWithRepetitionSeq returns an iterator over combinations with repetition
+// of size k out of elements in the elems slice, paired with their rank. The sequence
+// is empty if the arguments are invalid, see [CombinationWithRepetitionGenerator.Init]
+// for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func CombinationsWithRepetitionSeq[T any](k int, elems []T) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewCombinationWithRepetitionGenerator(k, elems)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+var (
+	_cwr_data = []struct {
+		input []int
+		want  [][]int
+		k     int
+	}{
+		{
+			input: []int{1, 2, 3},
+			want:  [][]int{{1}, {2}, {3}},
+			k:     1,
+		},
+		{
+			input: []int{1},
+			want:  [][]int{{1, 1, 1}},
+			k:     3,
+		},
+		{
+			input: []int{1, 2, 3},
+			want: [][]int{
+				{1, 1},
+				{1, 2},
+				{1, 3},
+				{2, 2},
+				{2, 3},
+				{3, 3},
+			},
+			k: 2,
+		},
+		{
+			input: []int{1, 2},
+			want: [][]int{
+				{1, 1, 1},
+				{1, 1, 2},
+				{1, 2, 2},
+				{2, 2, 2},
+			},
+			k: 3,
+		},
+		{
+			input: []int{1, 2, 3, 4},
+			want: [][]int{
+				{1, 1, 1},
+				{1, 1, 2},
+				{1, 1, 3},
+				{1, 1, 4},
+				{1, 2, 2},
+				{1, 2, 3},
+				{1, 2, 4},
+				{1, 3, 3},
+				{1, 3, 4},
+				{1, 4, 4},
+				{2, 2, 2},
+				{2, 2, 3},
+				{2, 2, 4},
+				{2, 3, 3},
+				{2, 3, 4},
+				{2, 4, 4},
+				{3, 3, 3},
+				{3, 3, 4},
+				{3, 4, 4},
+				{4, 4, 4},
+			},
+			k: 3,
+		},
+	}
+)
+
+func TestCombinationsWithRepetition(t *testing.T) {
+	for _, d := range _cwr_data {
+		d := d
+
+		t.Run(fmt.Sprintf("cr(%d,%d)", d.k, len(d.input)), func(t *testing.T) {
+			res, err := CombinationsWithRepetition(d.k, d.input)
+
+			if err != nil {
+				t.Errorf("Error'd with: %v", err)
+			}
+
+			if compareSliceOfSlices(res, d.want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, d.want)
+			}
+		})
+	}
+
+	if _, err := CombinationsWithRepetition(0, []int{1}); err == nil {
+		t.Errorf("Didn't err on k = 0")
+	}
+	if _, err := CombinationsWithRepetition(1, []int{}); err == nil {
+		t.Errorf("Didn't err on empty input")
+	}
+	if _, err := CombinationsWithRepetitionWithLimit(3, []int{1, 2, 3, 4}, MaxResults(19)); !errors.Is(err, ErrTooManyResults) {
+		t.Errorf("Want ErrTooManyResults, got %v", err)
+	}
+}
+
+func TestCombinationWithRepetitionCount(t *testing.T) {
+	if n := CombinationWithRepetitionCount(4, 6); n != 126 {
+		t.Errorf("Want 126, got %v", n)
+	}
+	if n := CombinationWithRepetitionCount(3, 1); n != 1 {
+		t.Errorf("Want 1, got %v", n)
+	}
+	if _, err := CombinationWithRepetitionCountChecked(50, 60); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Want ErrOverflow, got %v", err)
+	}
+	if n := CombinationWithRepetitionCountBig(50, 60).String(); n != BinomBig(50, 109).String() {
+		t.Errorf("Want %v, got %v", BinomBig(50, 109), n)
+	}
+}
+
+func TestCombinationWithRepetitionRank(t *testing.T) {
+	for _, cd := range _cwr_data {
+		cd := cd
+		n := len(cd.input)
+
+		t.Run(fmt.Sprintf("cr(%d,%d)", cd.k, n), func(t *testing.T) {
+			for r, w := range cd.want {
+				positions, err := CombinationWithRepetitionUnrank(cd.k, n, r)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				for i, p := range positions {
+					if cd.input[p] != w[i] {
+						t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", r, positions, w)
+						break
+					}
+				}
+
+				if rank, err := CombinationWithRepetitionRank(n, positions); err != nil || rank != r {
+					t.Errorf("Wrong rank for %v, got: %v (%v), want: %v", positions, rank, err, r)
+				}
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := CombinationWithRepetitionUnrank(2, 3, 6); err == nil {
+			t.Errorf("Didn't err on rank out of range")
+		}
+		if _, err := CombinationWithRepetitionUnrank(0, 3, 0); err == nil {
+			t.Errorf("Didn't err on k = 0")
+		}
+		if _, err := CombinationWithRepetitionRank(3, []int{1, 0}); err == nil {
+			t.Errorf("Didn't err on decreasing positions")
+		}
+		if _, err := CombinationWithRepetitionRank(3, []int{0, 3}); err == nil {
+			t.Errorf("Didn't err on position out of range")
+		}
+		if _, err := CombinationWithRepetitionRank(3, nil); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+	})
+}
+
+func TestCombinationWithRepetitionGenerator(t *testing.T) {
+	for _, cd := range _cwr_data {
+		cd := cd
+
+		t.Run(fmt.Sprintf("cr(%d,%d)", cd.k, len(cd.input)), func(t *testing.T) {
+			gen, err := NewCombinationWithRepetitionGenerator(cd.k, cd.input)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			testGenerator[int](t, gen, cd.want)
+		})
+	}
+
+	if _, err := NewCombinationWithRepetitionGenerator(0, []int{1}); err == nil {
+		t.Errorf("Didn't err on k = 0")
+	}
+}
+
+func TestCombinationWithRepetitionIndexGenerator(t *testing.T) {
+	for n := 1; n <= 4; n++ {
+		for k := 1; k <= 4; k++ {
+			t.Run(fmt.Sprintf("cr(%d,%d)", k, n), func(t *testing.T) {
+				gen, err := NewCombinationWithRepetitionIndexGenerator(k, n)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				want, _ := CombinationsWithRepetition(k, indices(n))
+				testGenerator[int](t, gen, want)
+			})
+		}
+	}
+
+	gen, _ := NewCombinationWithRepetitionIndexGenerator(2, 3)
+
+	if err := gen.Init(2, []int{5, 6, 7}); err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	if gen.Next(); slices.Compare(gen.Current(), []int{5, 5}) != 0 {
+		t.Errorf("Not equal after init with elements, \ngot: %v, \nwant: %v", gen.Current(), []int{5, 5})
+	}
+
+	if _, err := NewCombinationWithRepetitionIndexGenerator(0, 3); err == nil {
+		t.Errorf("Didn't err on k = 0")
+	}
+	if _, err := NewCombinationWithRepetitionIndexGenerator(2, 0); err == nil {
+		t.Errorf("Didn't err on n = 0")
+	}
+}
+
+func TestCombinationsWithRepetitionSeq(t *testing.T) {
+	for _, cd := range _cwr_data {
+		res := make([][]int, 0, len(cd.want))
+
+		for _, v := range CombinationsWithRepetitionSeq(cd.k, cd.input) {
+			res = append(res, slices.Clone(v))
+		}
+
+		if compareSliceOfSlices(res, cd.want) != 0 {
+			t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, cd.want)
+		}
+	}
+
+	for range CombinationsWithRepetitionSeq(0, []int{1, 2}) {
+		t.Errorf("Didn't return an empty sequence on invalid input")
+	}
+}
+
+func BenchmarkCombinationWithRepetitionGenerator(b *testing.B) {
+	items := []int{1, 2, 3, 4, 5, 6}
+
+	for k := 2; k <= 6; k++ {
+		k := k
+		gen := new(CombinationWithRepetitionGenerator[int])
+
+		b.Run(fmt.Sprintf("cr(%d,6)=%d", k, CombinationWithRepetitionCount(k, 6)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				gen.Init(k, items)
+				for gen.Next() {
+					gen.Current()
+				}
+			}
+		})
+	}
+}
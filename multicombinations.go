@@ -0,0 +1,709 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"math/big"
+	"slices"
+)
+
+// MultiCombinationCount returns the number of combinations of size k out of a multiset
+// with the given reps, i.e. the number of its distinct sub-multisets of size k.
+func MultiCombinationCount(k int, reps []int) int {
+	t, _ := multiCombinationTable(k, reps)
+	return t[k]
+}
+
+// MultiCombinationCountChecked returns the number of combinations of size k out of a multiset
+// with the given reps, or [ErrOverflow] if it doesn't fit in an int.
+func MultiCombinationCountChecked(k int, reps []int) (int, error) {
+	t, ok := multiCombinationTable(k, reps)
+
+	if !ok {
+		return 0, ErrOverflow
+	}
+
+	return t[k], nil
+}
+
+// MultiCombinationCountBig returns the number of combinations of size k out of a multiset
+// with the given reps as [big.Int].
+func MultiCombinationCountBig(k int, reps []int) *big.Int {
+	if k < 0 {
+		return big.NewInt(0)
+	}
+
+	// number of ways to choose r items out of the elements processed so far
+	f := make([]*big.Int, k+1)
+	f[0] = big.NewInt(1)
+
+	for r := 1; r <= k; r++ {
+		f[r] = big.NewInt(0)
+	}
+
+	for i := len(reps) - 1; i >= 0; i-- {
+		for r := k; r > 0; r-- {
+			for j := 1; j <= min(reps[i], r); j++ {
+				f[r].Add(f[r], f[r-j])
+			}
+		}
+	}
+
+	return f[k]
+}
+
+// Returns the table of numbers of multiset combinations, where t[i*(k+1)+r] is the number
+// of ways to choose r items out of the elements i..len(reps)-1, and reports whether all
+// numbers fit in an int. Numbers that don't fit are saturated to math.MaxInt.
+// The total count is t[k].
+func multiCombinationTable(k int, reps []int) ([]int, bool) {
+	if k < 0 {
+		return []int{0}, true
+	}
+
+	n := len(reps)
+	w := k + 1
+	t := make([]int, (n+1)*w)
+	t[n*w] = 1
+	valid := true
+
+	for i := n - 1; i >= 0; i-- {
+		for r := 0; r <= k; r++ {
+			sum := 0
+
+			for j := 0; j <= min(reps[i], r); j++ {
+				// both terms are within [0, math.MaxInt], so an overflow wraps around to a negative sum
+				if sum += t[(i+1)*w+r-j]; sum < 0 {
+					sum, valid = math.MaxInt, false
+				}
+			}
+
+			t[i*w+r] = sum
+		}
+	}
+
+	return t, valid
+}
+
+// Returns the number of ways to choose r items out of the elements u..n-1, when the
+// element u can be chosen at most a times, by using the table from multiCombinationTable.
+// The number is saturated to math.MaxInt like the ones in the table.
+func multiCombinationFill(t []int, k, u, a, r int) int {
+	w := k + 1
+	ret := 0
+
+	for j := 0; j <= min(a, r); j++ {
+		if ret += t[(u+1)*w+r-j]; ret < 0 {
+			ret = math.MaxInt
+		}
+	}
+
+	return ret
+}
+
+// MultiCombinationRank returns the rank of a multiset combination in the lexicographic
+// order of combinations of size len(positions) out of a multiset with the given reps.
+// The combination is given as non-decreasing positions of elements in the elems slice,
+// with the position i occurring at most reps[i] times.
+//
+// Returns an error if reps is empty or contains a value less than 1, if positions is empty,
+// or if positions don't match reps.
+func MultiCombinationRank(reps, positions []int) (int, error) {
+	if _, err := multisetCounts(reps); err != nil {
+		return 0, err
+	}
+
+	k := len(positions)
+
+	if k == 0 {
+		return 0, fmt.Errorf("empty input slice")
+	}
+
+	t, _ := multiCombinationTable(k, reps)
+	rank := 0
+	prev, used := 0, 0
+
+	for i, p := range positions {
+		if p < prev || p >= len(reps) || (p == prev && used >= reps[p]) {
+			return 0, fmt.Errorf("combination doesn't match reps")
+		}
+
+		for u := prev; u < p; u++ {
+			a := reps[u]
+
+			if u == prev {
+				a -= used
+			}
+
+			if a > 0 {
+				rank += multiCombinationFill(t, k, u, a-1, k-i-1)
+			}
+		}
+
+		if p == prev {
+			used++
+		} else {
+			prev, used = p, 1
+		}
+	}
+
+	return rank, nil
+}
+
+// MultiCombinationUnrank returns non-decreasing positions of the multiset combination at
+// the given rank in the lexicographic order of combinations of size k out of a multiset
+// with the given reps. It's the inverse of [MultiCombinationRank].
+//
+// Returns an error if reps is empty or contains a value less than 1, if k is less than 1
+// or bigger than the size of the multiset, or if the rank is not in the range
+// [0, MultiCombinationCount(k, reps)).
+func MultiCombinationUnrank(k int, reps []int, rank int) ([]int, error) {
+	if _, err := multisetCounts(reps); err != nil {
+		return nil, err
+	}
+
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be >= 1")
+	}
+
+	t, _ := multiCombinationTable(k, reps)
+
+	if t[k] == 0 {
+		return nil, fmt.Errorf("k is too large")
+	}
+
+	if rank < 0 || rank >= t[k] {
+		return nil, fmt.Errorf("rank out of range")
+	}
+
+	positions := make([]int, k)
+	multiCombinationUnrank(t, reps, rank, positions)
+
+	return positions, nil
+}
+
+// Writes positions of the multiset combination at the lexicographic rank into dest,
+// by using the table from multiCombinationTable for k = len(dest).
+func multiCombinationUnrank(t, reps []int, rank int, dest []int) {
+	k := len(dest)
+	prev, used := 0, 0
+
+	for i := range dest {
+		u := prev
+
+		for ; ; u++ {
+			a := reps[u]
+
+			if u == prev {
+				a -= used
+			}
+
+			if a == 0 {
+				continue
+			}
+
+			c := multiCombinationFill(t, k, u, a-1, k-i-1)
+
+			if rank < c {
+				break
+			}
+
+			rank -= c
+		}
+
+		dest[i] = u
+
+		if u == prev {
+			used++
+		} else {
+			prev, used = u, 1
+		}
+	}
+}
+
+// MultiCombinations returns all distinct combinations of size k out of a multiset, where
+// elems contains distinct elements of the multiset, and reps contains the number of
+// repetitions of every element. Combinations are produced in lexicographic order of
+// positions in elems, with elements in every combination ordered by their position.
+//
+// Returns an error if input slices are empty or of different lengths, if reps contains
+// a value less than 1, or if k is less than 1 or bigger than the size of the multiset.
+func MultiCombinations[T any](k int, elems []T, reps []int) ([][]T, error) {
+	return MultiCombinationsWithLimit(k, elems, reps)
+}
+
+// MultiCombinationsWithLimit is like [MultiCombinations], but returns [TooManyResultsError]
+// before allocating the results if there are more of them than allowed by opts.
+func MultiCombinationsWithLimit[T any](k int, elems []T, reps []int, opts ...LimitOption) ([][]T, error) {
+	t, err := MultiCombinationsTable(k, elems, reps, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Rows(), nil
+}
+
+// MultiCombinationsTable is like [MultiCombinationsWithLimit], but stores the results in a [Table].
+func MultiCombinationsTable[T any](k int, elems []T, reps []int, opts ...LimitOption) (*Table[T], error) {
+	gen, err := NewMultiCombinationGenerator(k, elems, reps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := MultiCombinationCountChecked(k, reps)
+
+	if err := checkLimit[T](count, err, k, opts, func() *big.Int { return MultiCombinationCountBig(k, reps) }); err != nil {
+		return nil, err
+	}
+
+	return fillTable(gen, count, k), nil
+}
+
+// MultiCombinationGenerator implements a [Generator] interface for generating
+// combinations of a multiset in lexicographic order of positions in the input slice.
+type MultiCombinationGenerator[T any] struct {
+	k, pos, from, to int
+	p, reps, t, suf  []int
+	idx              []int
+	elems, dest      []T
+	opts             []GeneratorOption
+	first, reverse   bool
+	change           Change
+}
+
+// Init initializes a generator for combinations of size k out of a multiset, where elems
+// contains distinct elements of the multiset, and reps contains the number of repetitions
+// of every element. Returns an error if input slices are empty or of different lengths,
+// if reps contains a value less than 1, or if k is less than 1 or bigger than the size
+// of the multiset.
+//
+// Supported options are [Reverse].
+func (gen *MultiCombinationGenerator[T]) Init(k int, elems []T, reps []int, opts ...GeneratorOption) error {
+	if len(elems) == 0 || len(reps) == 0 {
+		return fmt.Errorf("empty input slice(s)")
+	}
+
+	if len(elems) != len(reps) {
+		return fmt.Errorf("input lengths do not match")
+	}
+
+	return gen.init(k, elems, reps, opts)
+}
+
+// Initializes the generator for combinations of size k out of a multiset with the given
+// repetitions of its elements. If elems is nil, the generator is in index mode and produces
+// positions instead of elements, which requires T to be int.
+func (gen *MultiCombinationGenerator[T]) init(k int, elems []T, reps []int, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if _, err := multisetCounts(reps); err != nil {
+		return err
+	}
+
+	if k <= 0 {
+		return fmt.Errorf("k must be >= 1")
+	}
+
+	if o.gray {
+		return fmt.Errorf("gray code order is not supported")
+	}
+
+	n := len(reps)
+
+	if len(gen.suf) != n+1 {
+		gen.suf = make([]int, n+1)
+	}
+
+	gen.suf[n] = 0
+
+	for i := n - 1; i >= 0; i-- {
+		gen.suf[i] = gen.suf[i+1] + reps[i]
+	}
+
+	if k > gen.suf[0] {
+		return fmt.Errorf("k is too large")
+	}
+
+	if k != gen.k {
+		gen.dest = make([]T, k)
+		gen.p = make([]int, k)
+	}
+
+	gen.t, _ = multiCombinationTable(k, reps)
+	gen.k = k
+	gen.elems = elems
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.dest).([]int)
+	}
+
+	gen.reps = reps
+	gen.opts = opts
+	gen.reverse = o.reverse
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(0)
+}
+
+// Returns the number of combinations in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *MultiCombinationGenerator[T]) count() int {
+	return gen.t[gen.k]
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
+func (gen *MultiCombinationGenerator[T]) Reset() {
+	s, from, to := gen.dest, gen.from, gen.to
+	gen.init(gen.k, gen.elems, gen.reps, gen.opts)
+	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
+}
+
+// Current returns the internal slice that holds the current combination.
+// If you need to modify the returned slice, use [MultiCombinationGenerator.CurrentCopy] instead.
+func (gen *MultiCombinationGenerator[T]) Current() []T {
+	return gen.dest
+}
+
+// CurrentCopy returns a copy of the internal slice that holds the current combination.
+// If you don't need to modify the returned slice, use [MultiCombinationGenerator.Current] to avoid allocation.
+func (gen *MultiCombinationGenerator[T]) CurrentCopy() []T {
+	return slices.Clone(gen.dest)
+}
+
+// SetDest sets a destination slice that will receive the results.
+// Returns an error if there's not enough capacity in the slice.
+//
+// After the destination slice is set, subsequent calls to [MultiCombinationGenerator.Current]
+// will return the provided slice.
+func (gen *MultiCombinationGenerator[T]) SetDest(dest []T) error {
+	if got := cap(dest); got < gen.k {
+		return fmt.Errorf(capacityMsg(gen.k, got))
+	}
+
+	copy(dest, gen.dest)
+	gen.dest = dest
+
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
+	return nil
+}
+
+// Next produces a new combination in the generator. If it returns false,
+// there are no more combinations available.
+func (gen *MultiCombinationGenerator[T]) Next() bool {
+	if gen.k <= 0 || gen.pos+1 >= gen.to {
+		return false
+	}
+
+	if gen.first {
+		gen.first = false
+		gen.change = Change{Kind: ChangeAll}
+	} else {
+		// the rightmost position that can be increased by one, so that elements
+		// after it can still fill the rest of the combination
+		i := gen.k - 1
+
+		for gen.suf[gen.p[i]+1] < gen.k-i {
+			i--
+		}
+
+		v := gen.p[i] + 1
+		left := gen.reps[v]
+		gen.change = rangeChange(i, gen.k, gen.p[i], v)
+
+		for ; i < gen.k; i++ {
+			if left == 0 {
+				v++
+				left = gen.reps[v]
+			}
+
+			gen.p[i] = v
+			gen.set(i, v)
+			left--
+		}
+	}
+
+	gen.pos++
+
+	return true
+}
+
+// Prev produces the combination before the current one in the sequence of the generator.
+// If it returns false, there are no previous combinations available in the range.
+// Like [MultiCombinationGenerator.Next], it steps directly in the lexicographic order,
+// except right after [MultiCombinationGenerator.Seek] or at the end of the range, when
+// it seeks to the previous combination instead.
+func (gen *MultiCombinationGenerator[T]) Prev() bool {
+	if gen.change.Kind == ChangeNone {
+		return prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	if gen.pos-1 < gen.from {
+		return false
+	}
+
+	// the rightmost position that can be decreased by one, i.e. that is bigger
+	// than the previous one, or equal to it while the element has repetitions left
+	i := gen.k - 1
+
+	for ; i > 0; i-- {
+		v := gen.p[i] - 1
+
+		if gen.p[i-1] < v {
+			break
+		}
+
+		if gen.p[i-1] == v {
+			used := 1
+
+			for j := i - 2; j >= 0 && gen.p[j] == v; j-- {
+				used++
+			}
+
+			if used < gen.reps[v] {
+				break
+			}
+		}
+	}
+
+	gen.change = rangeChange(i, gen.k, gen.p[i], gen.p[i]-1)
+	gen.p[i]--
+
+	// elements after it are the biggest ones, with all of their repetitions
+	v := len(gen.reps) - 1
+	left := gen.reps[v]
+
+	for j := gen.k - 1; j > i; j-- {
+		if left == 0 {
+			v--
+			left = gen.reps[v]
+		}
+
+		gen.p[j] = v
+		left--
+	}
+
+	gen.fill(i)
+	gen.pos--
+
+	return true
+}
+
+// LastChange returns the change of the current combination made by the last call to
+// [MultiCombinationGenerator.Next] or [MultiCombinationGenerator.Prev]. Every combination after the first
+// one differs from the previous one in the last element ([ChangeReplace]),
+// or in elements from some position to the end ([ChangeRange]).
+func (gen *MultiCombinationGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current combination in the sequence of the generator,
+// or -1 if [MultiCombinationGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [MultiCombinationGenerator.Prev] is called.
+func (gen *MultiCombinationGenerator[T]) Position() int {
+	return gen.pos
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [MultiCombinationGenerator.Next] produces the combination
+// at that rank, and [MultiCombinationGenerator.Prev]
+// the one before it. Seeking to the end of the range set by [MultiCombinationGenerator.Range]
+// (MultiCombinationCount(k, reps) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *MultiCombinationGenerator[T]) Seek(rank int) error {
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.first = rank < gen.count()
+	gen.pos = rank - 1
+
+	if !gen.first {
+		rank--
+	}
+
+	multiCombinationUnrank(gen.t, gen.reps, rank, gen.p)
+
+	gen.fill(0)
+
+	return nil
+}
+
+// Writes elements at positions in p into the current combination, starting from the position i.
+func (gen *MultiCombinationGenerator[T]) fill(i int) {
+	for ; i < gen.k; i++ {
+		gen.set(i, gen.p[i])
+	}
+}
+
+// Writes the element at position x into the position i of the current combination.
+func (gen *MultiCombinationGenerator[T]) set(i, x int) {
+	if gen.idx != nil {
+		gen.idx[i] = x
+	} else {
+		gen.dest[i] = gen.elems[x]
+	}
+}
+
+// Range limits the generator to the combinations with ranks in the range [from, to)
+// and moves it to the rank from. [MultiCombinationGenerator.Reset] preserves the range, and
+// [MultiCombinationGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, MultiCombinationCount(k, reps)].
+func (gen *MultiCombinationGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *MultiCombinationGenerator[T]) end() {
+	gen.pos = gen.to
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *MultiCombinationGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// All returns an iterator over all combinations paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [MultiCombinationGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded combination and can be resumed with [MultiCombinationGenerator.Next] or restarted
+// with [MultiCombinationGenerator.Reset].
+func (gen *MultiCombinationGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [MultiCombinationGenerator.All], but it yields only the combinations.
+func (gen *MultiCombinationGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following combinations, as if by calling
+// [MultiCombinationGenerator.Next] and [MultiCombinationGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of combinations written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *MultiCombinationGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [MultiCombinationGenerator.NextBatch], but writes the combinations one after another
+// into dst, where every combination takes k elements. Writes as many combinations as fit into dst.
+func (gen *MultiCombinationGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.k)
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current combination, and shares only the input with the original.
+func (gen *MultiCombinationGenerator[T]) Clone() *MultiCombinationGenerator[T] {
+	c := *gen
+	c.dest = slices.Clone(gen.dest)
+	c.p = slices.Clone(gen.p)
+	c.t = slices.Clone(gen.t)
+	c.suf = slices.Clone(gen.suf)
+
+	if gen.idx != nil {
+		c.idx = any(c.dest).([]int)
+	}
+
+	return &c
+}
+
+// NewMultiCombinationGenerator creates and initializes a new MultiCombinationGenerator.
+// Arguments and returned errors are the same ones from the [MultiCombinationGenerator.Init] method.
+func NewMultiCombinationGenerator[T any](k int, elems []T, reps []int, opts ...GeneratorOption) (*MultiCombinationGenerator[T], error) {
+	gen := new(MultiCombinationGenerator[T])
+	err := gen.Init(k, elems, reps, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// NewMultiCombinationIndexGenerator creates a new MultiCombinationGenerator that produces
+// combinations of size k out of a multiset as positions of its distinct elements, i.e. as
+// integers in the range [0, len(reps)), where reps contains the number of repetitions of
+// every element, without requiring the input slice. Returns an error if reps is empty,
+// otherwise the same errors as [MultiCombinationGenerator.Init].
+func NewMultiCombinationIndexGenerator(k int, reps []int, opts ...GeneratorOption) (*MultiCombinationGenerator[int], error) {
+	if len(reps) == 0 {
+		return nil, fmt.Errorf("empty input slice(s)")
+	}
+
+	gen := new(MultiCombinationGenerator[int])
+
+	if err := gen.init(k, nil, reps, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// MultiCombinationsSeq returns an iterator over combinations of size k out of a multiset,
+// paired with their rank. The sequence is empty if the arguments are invalid, see
+// [MultiCombinationGenerator.Init] for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func MultiCombinationsSeq[T any](k int, elems []T, reps []int) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewMultiCombinationGenerator(k, elems, reps)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+var (
+	_mc_data = []struct {
+		input []string
+		reps  []int
+		want  [][]string
+		k     int
+	}{
+		{
+			input: []string{"A"},
+			reps:  []int{3},
+			want:  [][]string{{"A", "A"}},
+			k:     2,
+		},
+		{
+			input: []string{"A", "B", "C"},
+			reps:  []int{1, 1, 1},
+			want:  [][]string{{"A", "B"}, {"A", "C"}, {"B", "C"}},
+			k:     2,
+		},
+		{
+			input: []string{"A", "B", "C"},
+			reps:  []int{2, 1, 2},
+			want: [][]string{
+				{"A", "A"},
+				{"A", "B"},
+				{"A", "C"},
+				{"B", "C"},
+				{"C", "C"},
+			},
+			k: 2,
+		},
+		{
+			input: []string{"A", "B", "C"},
+			reps:  []int{2, 1, 2},
+			want: [][]string{
+				{"A", "A", "B"},
+				{"A", "A", "C"},
+				{"A", "B", "C"},
+				{"A", "C", "C"},
+				{"B", "C", "C"},
+			},
+			k: 3,
+		},
+		{
+			input: []string{"A", "B", "C"},
+			reps:  []int{2, 1, 2},
+			want:  [][]string{{"A", "A", "B", "C", "C"}},
+			k:     5,
+		},
+	}
+)
+
+// Returns positions of multiset combinations by filtering combinations with repetition
+// that don't match reps.
+func multiCombinationsFromRepetitions(k int, reps []int) [][]int {
+	positions := make([]int, len(reps))
+
+	for i := range positions {
+		positions[i] = i
+	}
+
+	res, _ := CombinationsWithRepetition(k, positions)
+
+	return slices.DeleteFunc(res, func(c []int) bool {
+		counts := make([]int, len(reps))
+
+		for _, p := range c {
+			if counts[p]++; counts[p] > reps[p] {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func TestMultiCombinations(t *testing.T) {
+	for _, d := range _mc_data {
+		d := d
+
+		t.Run(fmt.Sprintf("mc(%d,%v)", d.k, d.reps), func(t *testing.T) {
+			res, err := MultiCombinations(d.k, d.input, d.reps)
+
+			if err != nil {
+				t.Errorf("Error'd with: %v", err)
+			}
+
+			if compareSliceOfSlices(res, d.want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, d.want)
+			}
+		})
+	}
+
+	reps := []int{3, 1, 2, 4}
+
+	for k := 1; k <= 10; k++ {
+		res, _ := MultiCombinations(k, []int{0, 1, 2, 3}, reps)
+
+		if want := multiCombinationsFromRepetitions(k, reps); compareSliceOfSlices(res, want) != 0 {
+			t.Errorf("Not equal for k = %d, \ngot: %v, \nwant: %v", k, res, want)
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := MultiCombinations(0, []int{1}, []int{1}); err == nil {
+			t.Errorf("Didn't err on k = 0")
+		}
+		if _, err := MultiCombinations(3, []int{1}, []int{2}); err == nil {
+			t.Errorf("Didn't err on k too large")
+		}
+		if _, err := MultiCombinations(1, []int{1}, []int{0}); err == nil {
+			t.Errorf("Didn't err on rep = 0")
+		}
+		if _, err := MultiCombinations(1, []int{1, 2}, []int{1}); err == nil {
+			t.Errorf("Didn't err on different lengths")
+		}
+		if _, err := MultiCombinations(1, []int{}, []int{}); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+	})
+}
+
+func TestMultiCombinationCount(t *testing.T) {
+	if c := MultiCombinationCount(3, []int{2, 1, 2}); c != 5 {
+		t.Errorf("got: %v\n, want: 5", c)
+	}
+
+	if MultiCombinationCount(2, []int{1, 1, 1, 1}) != CombinationCount(2, 4) {
+		t.Errorf("not equivalent to combinations")
+	}
+
+	if MultiCombinationCount(3, []int{3, 3, 3, 3}) != CombinationWithRepetitionCount(3, 4) {
+		t.Errorf("not equivalent to combinations with repetition")
+	}
+
+	if c := MultiCombinationCount(4, []int{1, 1}); c != 0 {
+		t.Errorf("got: %v\n, want: 0", c)
+	}
+
+	reps := make([]int, 70)
+
+	for i := range reps {
+		reps[i] = 35
+	}
+
+	if _, err := MultiCombinationCountChecked(35, reps); !errors.Is(err, ErrOverflow) {
+		t.Errorf("got: %v\n, want: ErrOverflow", err)
+	}
+
+	if c := MultiCombinationCountBig(35, reps); c.Cmp(CombinationWithRepetitionCountBig(35, 70)) != 0 {
+		t.Errorf("got: %v\n, want: %v", c, CombinationWithRepetitionCountBig(35, 70))
+	}
+}
+
+func TestMultiCombinationRank(t *testing.T) {
+	reps := []int{3, 1, 2, 4}
+
+	for k := 1; k <= 10; k++ {
+		for r, w := range multiCombinationsFromRepetitions(k, reps) {
+			positions, err := MultiCombinationUnrank(k, reps, r)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			if slices.Compare(positions, w) != 0 {
+				t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", r, positions, w)
+			}
+
+			if rank, err := MultiCombinationRank(reps, positions); err != nil || rank != r {
+				t.Errorf("Wrong rank for %v, got: %v (%v), want: %v", positions, rank, err, r)
+			}
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := MultiCombinationUnrank(2, []int{2, 1}, 3); err == nil {
+			t.Errorf("Didn't err on rank out of range")
+		}
+		if _, err := MultiCombinationUnrank(4, []int{2, 1}, 0); err == nil {
+			t.Errorf("Didn't err on k too large")
+		}
+		if _, err := MultiCombinationRank([]int{2, 1}, []int{1, 1}); err == nil {
+			t.Errorf("Didn't err on too many repetitions")
+		}
+		if _, err := MultiCombinationRank([]int{2, 1}, []int{1, 0}); err == nil {
+			t.Errorf("Didn't err on decreasing positions")
+		}
+		if _, err := MultiCombinationRank([]int{2, 1}, nil); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+	})
+}
+
+func TestMultiCombinationGenerator(t *testing.T) {
+	for _, d := range _mc_data {
+		d := d
+
+		t.Run(fmt.Sprintf("mc(%d,%v)", d.k, d.reps), func(t *testing.T) {
+			gen, err := NewMultiCombinationGenerator(d.k, d.input, d.reps)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			testGenerator[string](t, gen, d.want)
+		})
+	}
+
+	reps := []int{3, 1, 2, 4}
+
+	for k := 1; k <= 10; k++ {
+		gen, _ := NewMultiCombinationGenerator(k, []int{0, 1, 2, 3}, reps)
+		testGenerator[int](t, gen, multiCombinationsFromRepetitions(k, reps))
+	}
+}
+
+func TestMultiCombinationIndexGenerator(t *testing.T) {
+	reps := []int{2, 1, 3}
+
+	for k := 1; k <= 6; k++ {
+		gen, err := NewMultiCombinationIndexGenerator(k, reps)
+
+		if err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		want, _ := MultiCombinations(k, indices(len(reps)), reps)
+		testGenerator[int](t, gen, want)
+	}
+
+	gen, _ := NewMultiCombinationIndexGenerator(3, reps, Reverse())
+	want, _ := MultiCombinations(3, indices(len(reps)), reps)
+
+	if !gen.Prev() || !slices.Equal(gen.Current(), want[len(want)-1]) {
+		t.Errorf("Not at the end with Reverse, got: %v, want: %v", gen.Current(), want[len(want)-1])
+	}
+
+	if _, err := NewMultiCombinationIndexGenerator(1, nil); err == nil {
+		t.Errorf("Didn't err on empty reps")
+	}
+}
+
+func TestMultiCombinationsSeq(t *testing.T) {
+	for _, d := range _mc_data {
+		res := make([][]string, 0, len(d.want))
+
+		for _, v := range MultiCombinationsSeq(d.k, d.input, d.reps) {
+			res = append(res, slices.Clone(v))
+		}
+
+		if compareSliceOfSlices(res, d.want) != 0 {
+			t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, d.want)
+		}
+	}
+
+	for range MultiCombinationsSeq(3, []int{1}, []int{1}) {
+		t.Errorf("Didn't return an empty sequence on invalid input")
+	}
+}
+
+func BenchmarkMultiCombinationGenerator(b *testing.B) {
+	items := []string{"A", "B", "C", "D"}
+	reps := []int{2, 1, 3, 2}
+	gen := new(MultiCombinationGenerator[string])
+
+	for k := 2; k <= 6; k++ {
+		k := k
+
+		b.Run(fmt.Sprintf("mc(%d,AABCCCDD)=%d", k, MultiCombinationCount(k, reps)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				gen.Init(k, items, reps)
+				for gen.Next() {
+					gen.Current()
+				}
+			}
+		})
+	}
+}
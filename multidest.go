@@ -0,0 +1,97 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import "fmt"
+
+// Apply writes elements of src at the given positions into dst, i.e. dst[i] = src[indices[i]].
+// Positions are usually produced by an index generator, e.g. [NewPermutationIndexGenerator].
+// Returns an error if there's not enough capacity in dst.
+func Apply[T any](indices []int, dst, src []T) error {
+	if got := cap(dst); got < len(indices) {
+		return fmt.Errorf(capacityMsg(len(indices), got))
+	}
+
+	dst = dst[:len(indices)]
+
+	for i, p := range indices {
+		dst[i] = src[p]
+	}
+
+	return nil
+}
+
+// ApplyCopy is like [Apply], but writes the elements into a new slice.
+func ApplyCopy[T any](indices []int, src []T) []T {
+	dst := make([]T, len(indices))
+	Apply(indices, dst, src)
+
+	return dst
+}
+
+// MultiDest wraps an index generator and writes every arrangement it produces into
+// several destination slices at once, each one with elements from its own source slice.
+// It allows permuting parallel slices of different types in lockstep without building
+// a slice of structs first. Destinations are added with [AddDest].
+type MultiDest struct {
+	gen   Generator[int]
+	dests []func([]int)
+}
+
+// NewMultiDest creates a new [MultiDest] that wraps the generator of positions,
+// e.g. the one created by [NewPermutationIndexGenerator].
+//
+// The wrapped generator can still be used directly, e.g. to seek to another position,
+// but it must be advanced through the MultiDest for destinations to be updated.
+func NewMultiDest(gen Generator[int]) *MultiDest {
+	return &MultiDest{gen: gen}
+}
+
+// AddDest adds a destination slice to md that will receive elements of src at the positions
+// produced by the wrapped generator on every call to [MultiDest.Next]. Like in
+// [Generator.SetDest], the destination needs enough capacity for the largest result of the
+// generator. Returns an error if there's not enough capacity in the slice.
+func AddDest[T any](md *MultiDest, dst, src []T) error {
+	if need, got := cap(md.gen.Current()), cap(dst); got < need {
+		return fmt.Errorf(capacityMsg(need, got))
+	}
+
+	md.dests = append(md.dests, func(indices []int) {
+		Apply(indices, dst, src)
+	})
+
+	return nil
+}
+
+// Generator returns the wrapped generator.
+func (md *MultiDest) Generator() Generator[int] {
+	return md.gen
+}
+
+// Next produces a new arrangement in the wrapped generator and writes it into all
+// destination slices. If it returns false, there are no more arrangements available.
+func (md *MultiDest) Next() bool {
+	if !md.gen.Next() {
+		return false
+	}
+
+	indices := md.gen.Current()
+
+	for _, apply := range md.dests {
+		apply(indices)
+	}
+
+	return true
+}
+
+// Current returns the positions of the current arrangement.
+func (md *MultiDest) Current() []int {
+	return md.gen.Current()
+}
+
+// Reset resets the wrapped generator.
+func (md *MultiDest) Reset() {
+	md.gen.Reset()
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	src := []string{"A", "B", "C"}
+	dst := make([]string, 3)
+
+	if err := Apply([]int{2, 0, 1}, dst, src); err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	if want := []string{"C", "A", "B"}; !slices.Equal(dst, want) {
+		t.Errorf("Not equal, \ngot: %v, \nwant: %v", dst, want)
+	}
+
+	if got, want := ApplyCopy([]int{1, 1}, src), []string{"B", "B"}; !slices.Equal(got, want) {
+		t.Errorf("Not equal (copy), \ngot: %v, \nwant: %v", got, want)
+	}
+
+	if err := Apply([]int{0, 1}, make([]string, 1), src); err == nil {
+		t.Errorf("Didn't err on insufficient capacity")
+	}
+}
+
+func TestMultiDest(t *testing.T) {
+	names := []string{"A", "B", "C"}
+	weights := []float64{0.5, 1.5, 2.5}
+
+	gen, _ := NewPermutationIndexGenerator(len(names))
+	md := NewMultiDest(gen)
+	pnames, pweights := make([]string, 3), make([]float64, 3)
+
+	if err := AddDest(md, pnames, names); err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+	if err := AddDest(md, pweights, weights); err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+	if err := AddDest(md, make([]int, 2), []int{1, 2, 3}); err == nil {
+		t.Errorf("Didn't err on insufficient capacity")
+	}
+
+	want, _ := Permutations(names)
+	i := 0
+
+	for md.Next() {
+		if !slices.Equal(pnames, want[i]) {
+			t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", i, pnames, want[i])
+		}
+
+		for j, n := range pnames {
+			if w := weights[slices.Index(names, n)]; pweights[j] != w {
+				t.Errorf("Not in lockstep at %v, names: %v, weights: %v", i, pnames, pweights)
+				break
+			}
+		}
+
+		if !slices.Equal(ApplyCopy(md.Current(), names), pnames) {
+			t.Errorf("Current doesn't match at %v", i)
+		}
+
+		i++
+	}
+
+	if i != len(want) {
+		t.Errorf("Wrong number of results, got: %v, want: %v", i, len(want))
+	}
+
+	md.Reset()
+
+	if md.Next(); !slices.Equal(pnames, want[0]) {
+		t.Errorf("Not reset, got: %v, want: %v", pnames, want[0])
+	}
+}
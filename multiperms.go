@@ -5,23 +5,140 @@
 package kombinat
 
 import (
+	"encoding/json"
 	"fmt"
+	"iter"
+	"math/big"
 	"slices"
 )
 
 func MultiPermutationsCount(reps []int) int {
+	return Multinomial(reps)
+}
+
+// MultiPermutationsCountChecked returns the number of permutations of a multiset with
+// the given reps, or [ErrOverflow] if it doesn't fit in an int.
+func MultiPermutationsCountChecked(reps []int) (int, error) {
+	return MultinomialChecked(reps)
+}
+
+// MultiPermutationsCountBig returns the number of permutations of a multiset with
+// the given reps as [big.Int].
+func MultiPermutationsCountBig(reps []int) *big.Int {
+	return MultinomialBig(reps)
+}
+
+// MultiPermutationRank returns the rank of a multiset permutation in the lexicographic
+// order of permutations of a multiset with the given reps. The permutation is given
+// as a slice of positions of elements in the elems slice, with the position i
+// occurring exactly reps[i] times.
+//
+// Returns an error if reps is empty or contains a value less than 1,
+// or if perm doesn't match reps.
+func MultiPermutationRank(reps, perm []int) (int, error) {
+	counts, err := multisetCounts(reps)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, v := range perm {
+		if v < 0 || v >= len(counts) || counts[v] == 0 {
+			return 0, fmt.Errorf("permutation doesn't match reps")
+		}
+
+		counts[v]--
+	}
+
+	for _, c := range counts {
+		if c != 0 {
+			return 0, fmt.Errorf("permutation doesn't match reps")
+		}
+	}
+
+	copy(counts, reps)
+	rank := 0
+
+	for _, v := range perm {
+		for u := 0; u < v; u++ {
+			if counts[u] > 0 {
+				counts[u]--
+				rank += MultiPermutationsCount(counts)
+				counts[u]++
+			}
+		}
+
+		counts[v]--
+	}
+
+	return rank, nil
+}
+
+// MultiPermutationUnrank returns the multiset permutation at the given rank in the
+// lexicographic order of permutations of a multiset with the given reps. The result
+// is a slice of positions of elements in the elems slice. It's the inverse of
+// [MultiPermutationRank].
+//
+// Returns an error if reps is empty or contains a value less than 1,
+// or if the rank is not in the range [0, MultiPermutationsCount(reps)).
+func MultiPermutationUnrank(reps []int, rank int) ([]int, error) {
+	counts, err := multisetCounts(reps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rank < 0 || rank >= MultiPermutationsCount(reps) {
+		return nil, fmt.Errorf("rank out of range")
+	}
+
 	n := 0
-	for _, k := range reps {
-		n += k
+	for _, r := range reps {
+		n += r
 	}
 
-	n = Fac(n)
+	perm := make([]int, n)
+	multiPermutationUnrank(counts, rank, perm)
 
-	for _, k := range reps {
-		n /= Fac(k)
+	return perm, nil
+}
+
+// Writes the multiset permutation at the lexicographic rank into dest.
+// Counts are consumed in the process.
+func multiPermutationUnrank(counts []int, rank int, dest []int) {
+	for i := range dest {
+		for v := range counts {
+			if counts[v] == 0 {
+				continue
+			}
+
+			counts[v]--
+			c := saturate(MultiPermutationsCountChecked(counts))
+
+			if rank < c {
+				dest[i] = v
+				break
+			}
+
+			rank -= c
+			counts[v]++
+		}
 	}
+}
 
-	return n
+// Validates reps and returns a copy of it.
+func multisetCounts(reps []int) ([]int, error) {
+	if len(reps) == 0 {
+		return nil, fmt.Errorf("empty input slice(s)")
+	}
+
+	for _, r := range reps {
+		if r <= 0 {
+			return nil, fmt.Errorf("value of a rep must be >= 1")
+		}
+	}
+
+	return slices.Clone(reps), nil
 }
 
 // This function generates permutations of a multiset by following
@@ -68,94 +185,141 @@ func MultiPermutationsCount(reps []int) int {
 // [Algorithm 1]: https://dl.acm.org/doi/10.5555/1496770.1496877
 // [multipermute]: https://github.com/ekg/multipermute
 func MultiPermutations[T any](elems []T, reps []int) ([][]T, error) {
-	lelems := len(elems)
-	lreps := len(reps)
+	return MultiPermutationsWithLimit(elems, reps)
+}
 
-	if lelems == 0 || lreps == 0 {
-		return nil, fmt.Errorf("empty input slice(s)")
-	}
+// MultiPermutationsWithLimit is like [MultiPermutations], but returns [TooManyResultsError]
+// before allocating the results if there are more of them than allowed by opts.
+func MultiPermutationsWithLimit[T any](elems []T, reps []int, opts ...LimitOption) ([][]T, error) {
+	t, err := MultiPermutationsTable(elems, reps, opts...)
 
-	if lelems != lreps {
-		return nil, fmt.Errorf("input lengths do not match")
-	}
-
-	if lelems == 1 && lreps == 1 && reps[0] == 1 {
-		return [][]T{{elems[0]}}, nil
+	if err != nil {
+		return nil, err
 	}
 
-	n := 0
+	return t.Rows(), nil
+}
 
-	for i := 0; i < len(reps); i++ {
-		if reps[i] <= 0 {
-			return nil, fmt.Errorf("value of a rep must be >= 1")
-		}
+// MultiPermutationsTable is like [MultiPermutationsWithLimit], but stores the results in a [Table].
+func MultiPermutationsTable[T any](elems []T, reps []int, opts ...LimitOption) (*Table[T], error) {
+	gen, err := NewMultiPermutationGenerator(elems, reps)
 
-		n += reps[i]
+	if err != nil {
+		return nil, err
 	}
 
-	// init
-
-	res := make([][]T, 0, n)
-	init := make([]int, 0, n)
+	n := gen.n
+	count, err := MultiPermutationsCountChecked(reps)
 
-	for i := 0; i < len(reps); i++ {
-		for j := 0; j < reps[i]; j++ {
-			init = append(init, i)
-		}
+	if err := checkLimit[T](count, err, n, opts, func() *big.Int { return MultiPermutationsCountBig(reps) }); err != nil {
+		return nil, err
 	}
 
-	h := &listElement[int]{init[0], nil}
+	return fillTable(gen, count, n), nil
+}
 
-	for _, el := range init[1:] {
-		h = &listElement[int]{el, h}
-	}
+// Writes the multiset permutation at the given rank of the order produced by [Algorithm 1]
+// into dest, with counts holding the number of repetitions of every value.
+//
+// The order is defined recursively by the last value. Let m be the smallest value,
+// and x1 < x2 < ... < xk the other values. Then the order is:
+//
+//	S1·m, R(E-x1)·x1, S2·m, R(E-x2)·x2, ..., Sk·m, R(E-xk)·xk
+//
+// where R(E-x) is the order of the multiset without one x, rotated so that its
+// first permutation comes last, and S1, S2, ..., Sk are consecutive parts of the
+// order of the multiset without one m. Part St ends with the permutation made of
+// the remaining values in non-increasing order followed by xt, which is either the
+// first permutation of that order, or the last one of its block R(E-m-xt)·xt.
+//
+// [Algorithm 1]: https://dl.acm.org/doi/10.5555/1496770.1496877
+func prefixShiftUnrank(counts []int, rank int, dest []int) {
+	for n := len(dest); n > 0; n-- {
+		m := slices.IndexFunc(counts, func(c int) bool { return c > 0 })
+		skipped := 0
+		last := m
+
+		for x := m + 1; x < len(counts); x++ {
+			if counts[x] == 0 {
+				continue
+			}
 
-	i := h.nth(len(init) - 2)
-	j := h.nth(len(init) - 1)
-	var s, t *listElement[int]
+			start := prefixShiftBlockStart(counts, x)
+			counts[x]--
+			size, err := MultiPermutationsCountChecked(counts)
+			counts[x]++
 
-	temp := make([]T, n)
-	dump(h, elems, n, temp)
-	res = append(res, slices.Clone(temp))
+			if rank < start {
+				break
+			}
 
-	// loop
+			if rank < satAdd(start, saturate(size, err)) {
+				// the block is rotated, so its first permutation comes last, and
+				// a block too big for an int doesn't reach its end
+				last = x
+				rank = rank - start + 1
 
-	for j.next != nil || j.value < h.value {
+				if err == nil {
+					rank %= size
+				}
 
-		if j.next != nil && i.value >= j.next.value {
-			s = j
-		} else if i.next != nil { // <- fix for [A,B]
-			s = i
-		} else {
-			s = h
+				break
+			}
+
+			skipped += size
 		}
 
-		t = s.next
-		s.next = t.next
-		t.next = h
+		if last == m {
+			rank -= skipped
+		}
 
-		if t.value < h.value {
-			i = t
+		dest[n-1] = last
+		counts[last]--
+	}
+}
+
+// Returns the rank of the first permutation of the block R(E-x)·x
+// in the order described in [prefixShiftUnrank].
+func prefixShiftBlockStart(counts []int, x int) int {
+	m := slices.IndexFunc(counts, func(c int) bool { return c > 0 })
+	start := 0
+
+	for y := m + 1; y < x; y++ {
+		if counts[y] > 0 {
+			counts[y]--
+			start = satAdd(start, saturate(MultiPermutationsCountChecked(counts)))
+			counts[y]++
 		}
+	}
 
-		j = i.next
-		h = t
+	// add the size of S1, S2, ..., St
+	counts[m]--
+	defer func() { counts[m]++ }()
 
-		dump(h, elems, n, temp)
-		res = append(res, slices.Clone(temp))
+	if slices.IndexFunc(counts, func(c int) bool { return c > 0 }) == x {
+		return satAdd(start, 1)
 	}
 
-	return res, nil
+	end := prefixShiftBlockStart(counts, x)
+	counts[x]--
+	end = satAdd(end, saturate(MultiPermutationsCountChecked(counts)))
+	counts[x]++
+
+	return satAdd(start, end)
 }
 
 // MultiPermutationGenerator implements a [Generator] interface for generating multiset
 // permutations by an algorithm described in [MultiPermutations].
 type MultiPermutationGenerator[T any] struct {
 	elems, dest             []T
-	reps                    []int
-	n                       int
+	reps, p, idx            []int
+	n, pos, from, to        int
+	ii, jj                  int // positions of i and j in the list
 	i, j, s, t, h           *listElement[int]
+	opts                    []GeneratorOption
 	first, returned, single bool
+	lex, reverse            bool
+	change                  Change
 }
 
 // Init initializes a generator of multiset permutations.
@@ -165,7 +329,11 @@ type MultiPermutationGenerator[T any] struct {
 //   - reps: number of repetitions per element, rep must be 1 or higher.
 //
 // Number of items in both slices must match, as reps correspond to the elems.
-func (gen *MultiPermutationGenerator[T]) Init(elems []T, reps []int) error {
+//
+// Supported options are [Lexicographic] and [Reverse]. With the [Lexicographic] option,
+// the generator doesn't use the prefix shift algorithm, so consecutive permutations
+// can differ in more than a rotation of a prefix.
+func (gen *MultiPermutationGenerator[T]) Init(elems []T, reps []int, opts ...GeneratorOption) error {
 	lelems := len(elems)
 	lreps := len(reps)
 
@@ -177,7 +345,20 @@ func (gen *MultiPermutationGenerator[T]) Init(elems []T, reps []int) error {
 		return fmt.Errorf("input lengths do not match")
 	}
 
-	gen.single = lelems == 1 && lreps == 1
+	return gen.init(elems, reps, opts)
+}
+
+// Initializes the generator for permutations of a multiset with the given repetitions
+// of its elements. If elems is nil, the generator is in index mode and produces positions
+// instead of elements, which requires T to be int.
+func (gen *MultiPermutationGenerator[T]) init(elems []T, reps []int, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if o.gray {
+		return fmt.Errorf("gray code order is not supported")
+	}
+
+	gen.single = len(reps) == 1
 
 	n := 0
 
@@ -209,21 +390,60 @@ func (gen *MultiPermutationGenerator[T]) Init(elems []T, reps []int) error {
 
 	gen.i = gen.h.nth(len(init) - 2)
 	gen.j = gen.h.nth(len(init) - 1)
+	gen.ii, gen.jj = max(len(init)-2, 1), len(init)-1
 
 	gen.first = true
+	gen.change = Change{}
 	gen.returned = false
+	gen.pos = -1
 	gen.n = n
 	gen.elems = elems
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.dest).([]int)
+	}
+
 	gen.reps = reps
+	gen.opts = opts
+	gen.lex = o.lex
+	gen.reverse = o.reverse
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.lex {
+		gen.p = init
+	}
+
+	if gen.reverse {
+		gen.end()
+	}
 
 	return nil
 }
 
-// Reset resets the generator to the beginning of the sequence.
+// Returns the number of permutations in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *MultiPermutationGenerator[T]) count() int {
+	return saturate(MultiPermutationsCountChecked(gen.reps))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
 func (gen *MultiPermutationGenerator[T]) Reset() {
-	s := gen.dest
-	gen.Init(gen.elems, gen.reps)
+	s, from, to := gen.dest, gen.from, gen.to
+	gen.init(gen.elems, gen.reps, gen.opts)
 	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
 }
 
 // Current returns the internal slice that holds the current permutation.
@@ -251,53 +471,96 @@ func (gen *MultiPermutationGenerator[T]) SetDest(dest []T) error {
 	copy(dest, gen.dest)
 	gen.dest = dest
 
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
 	return nil
 }
 
 // Next produces a new permutation in the generator. If it returns false,
 // there are no more permutations available.
 func (gen *MultiPermutationGenerator[T]) Next() bool {
+	if gen.pos+1 >= gen.to {
+		return false
+	}
+
 	// single element case
 	if gen.single {
 		if !gen.returned {
 			for i := 0; i < gen.reps[0]; i++ {
-				gen.dest[i] = gen.elems[0]
+				gen.set(i, 0)
 			}
 			gen.returned = true
+			gen.change = Change{Kind: ChangeAll}
+			gen.pos++
 			return true
 		}
 
 		return false
 	}
 
+	if gen.lex {
+		if gen.first {
+			gen.first = false
+			gen.change = Change{Kind: ChangeAll}
+			gen.fill(0)
+		} else {
+			i := nextPermutation(gen.p)
+
+			if i < 0 {
+				return false
+			}
+
+			gen.fill(i)
+			gen.change = permutationChange(i, gen.n)
+		}
+
+		gen.pos++
+		return true
+	}
+
 	if gen.first {
-		dump(gen.h, gen.elems, gen.n, gen.dest)
+		gen.dump()
 		gen.first = false
+		gen.change = Change{Kind: ChangeAll}
+		gen.pos++
 		return true
 	}
 
 	if gen.j.next != nil || gen.j.value < gen.h.value {
 
+		// position of the node s
+		k := gen.jj
+
 		if gen.j.next != nil && gen.i.value >= gen.j.next.value {
 			gen.s = gen.j
 		} else if gen.i.next != nil { // <- fix for [A,B]
-			gen.s = gen.i
+			gen.s, k = gen.i, gen.ii
 		} else {
-			gen.s = gen.h
+			gen.s, k = gen.h, 0
 		}
 
 		gen.t = gen.s.next
+
+		// the node t moves from its position to the beginning of the list,
+		// and nodes before it move one position to the right
+		gen.change = Change{Kind: ChangeRotate, I: 0, J: k + 1}
 		gen.s.next = gen.t.next
 		gen.t.next = gen.h
 
-		if gen.t.value < gen.h.value {
-			gen.i = gen.t
+		switch {
+		case gen.i == gen.t || gen.t.value < gen.h.value:
+			gen.i, gen.ii = gen.t, 0
+		case gen.ii <= k:
+			gen.ii++
 		}
 
-		gen.j = gen.i.next
+		gen.j, gen.jj = gen.i.next, gen.ii+1
 		gen.h = gen.t
 
-		dump(gen.h, gen.elems, gen.n, gen.dest)
+		gen.dump()
+		gen.pos++
 
 		return true
 	}
@@ -305,11 +568,340 @@ func (gen *MultiPermutationGenerator[T]) Next() bool {
 	return false
 }
 
+// Prev produces the permutation before the current one in the sequence of the generator.
+// If it returns false, there are no previous permutations available in the range.
+// In the prefix shift order, Prev seeks to the previous permutation, which takes
+// as much time as [MultiPermutationGenerator.Seek], and [MultiPermutationGenerator.LastChange]
+// reports [ChangeAll] after it. With the [Lexicographic] option, it steps back directly.
+func (gen *MultiPermutationGenerator[T]) Prev() bool {
+	if !gen.lex || gen.single || gen.change.Kind == ChangeNone {
+		return prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	if gen.pos-1 < gen.from {
+		return false
+	}
+
+	i := prevPermutation(gen.p)
+	gen.fill(i)
+	gen.change = permutationChange(i, gen.n)
+	gen.pos--
+
+	return true
+}
+
+// Writes elements at positions in p into the current permutation, starting from the position i.
+func (gen *MultiPermutationGenerator[T]) fill(i int) {
+	for ; i < gen.n; i++ {
+		gen.set(i, gen.p[i])
+	}
+}
+
+// Writes elements at positions in the list into the current permutation.
+func (gen *MultiPermutationGenerator[T]) dump() {
+	for i, e := 0, gen.h; i < gen.n && e != nil; i, e = i+1, e.next {
+		gen.set(i, e.value)
+	}
+}
+
+// Writes the element at position x into the position i of the current permutation.
+func (gen *MultiPermutationGenerator[T]) set(i, x int) {
+	if gen.idx != nil {
+		gen.idx[i] = x
+	} else {
+		gen.dest[i] = gen.elems[x]
+	}
+}
+
+// LastChange returns the change of the current permutation made by the last call to
+// [MultiPermutationGenerator.Next]. Every permutation after the first
+// one differs from the previous one by a prefix shift ([ChangeRotate] with I = 0).
+// With the [Lexicographic] option, permutations differ in elements from some position
+// to the end ([ChangeRange]), or by a swap of the last two elements.
+func (gen *MultiPermutationGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current permutation in the sequence of the generator,
+// or -1 if [MultiPermutationGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [MultiPermutationGenerator.Prev] is called.
+func (gen *MultiPermutationGenerator[T]) Position() int {
+	return gen.pos
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [MultiPermutationGenerator.Next] produces the permutation
+// at that rank, and [MultiPermutationGenerator.Prev]
+// the one before it. Seeking to the end of the range set by [MultiPermutationGenerator.Range]
+// (MultiPermutationsCount(reps) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *MultiPermutationGenerator[T]) Seek(rank int) error {
+	count := gen.count()
+
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.pos = rank - 1
+	gen.first = rank < count
+
+	if gen.single {
+		gen.returned = !gen.first
+		return nil
+	}
+
+	if !gen.first {
+		rank--
+	}
+
+	if gen.lex {
+		multiPermutationUnrank(slices.Clone(gen.reps), rank, gen.p)
+		gen.fill(0)
+
+		return nil
+	}
+
+	perm := make([]int, gen.n)
+	prefixShiftUnrank(slices.Clone(gen.reps), rank, perm)
+
+	// i points to the first node followed by a bigger value,
+	// or to the second-last node if there's none
+	gen.i = nil
+	e := gen.h
+
+	for k, v := range perm {
+		e.value = v
+
+		if gen.i == nil && (k == gen.n-2 || k < gen.n-2 && v < perm[k+1]) {
+			gen.i, gen.ii = e, k
+		}
+
+		e = e.next
+	}
+
+	gen.j, gen.jj = gen.i.next, gen.ii+1
+
+	if !gen.first {
+		gen.dump()
+	}
+
+	return nil
+}
+
+// Range limits the generator to the permutations with ranks in the range [from, to)
+// and moves it to the rank from. [MultiPermutationGenerator.Reset] preserves the range, and
+// [MultiPermutationGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, MultiPermutationsCount(reps)].
+func (gen *MultiPermutationGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *MultiPermutationGenerator[T]) end() {
+	gen.pos = gen.to
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *MultiPermutationGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// All returns an iterator over all permutations paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [MultiPermutationGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded permutation and can be resumed with [MultiPermutationGenerator.Next] or restarted with
+// [MultiPermutationGenerator.Reset].
+func (gen *MultiPermutationGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [MultiPermutationGenerator.All], but it yields only the permutations.
+func (gen *MultiPermutationGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following permutations, as if by calling
+// [MultiPermutationGenerator.Next] and [MultiPermutationGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of permutations written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *MultiPermutationGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [MultiPermutationGenerator.NextBatch], but writes the permutations one after another
+// into dst, where every permutation takes as many elements as there are in the multiset.
+// Writes as many permutations as fit into dst.
+func (gen *MultiPermutationGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.n)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler]. It encodes the state of the algorithm,
+// the position of the generator, its range and options, and the numbers of repetitions, but
+// not the elements, so the state can be restored only into a generator initialized with the
+// same input and options.
+func (gen *MultiPermutationGenerator[T]) MarshalBinary() ([]byte, error) {
+	return gen.state().MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]. It restores the state encoded by
+// [MultiPermutationGenerator.MarshalBinary] into the generator, which must be initialized with the
+// same input and options, so that [MultiPermutationGenerator.Current] returns the same permutation, and the following
+// call to [MultiPermutationGenerator.Next] continues the sequence. Returns an error if the state is
+// invalid or if it doesn't match the generator.
+func (gen *MultiPermutationGenerator[T]) UnmarshalBinary(data []byte) error {
+	st := new(generatorState)
+
+	if err := st.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// MarshalJSON implements [json.Marshaler]. It's the JSON equivalent of [MultiPermutationGenerator.MarshalBinary].
+func (gen *MultiPermutationGenerator[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gen.state())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It's the JSON equivalent of [MultiPermutationGenerator.UnmarshalBinary].
+func (gen *MultiPermutationGenerator[T]) UnmarshalJSON(data []byte) error {
+	st := new(generatorState)
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// Returns the checkpoint of the generator. Its variables are the positions of the nodes
+// i and j in the list and the first and returned flags, and its slice is the order of
+// values in the list, or p with the [Lexicographic] option.
+func (gen *MultiPermutationGenerator[T]) state() *generatorState {
+	st := newState(gen.opts, gen.pos, gen.from, gen.to, gen.change, gen.reps...)
+	st.Vars = []int{gen.ii, gen.jj, boolVar(gen.first), boolVar(gen.returned)}
+
+	if gen.lex {
+		st.Slices = [][]int{slices.Clone(gen.p)}
+		return st
+	}
+
+	list := make([]int, 0, gen.n)
+
+	for e := gen.h; e != nil; e = e.next {
+		list = append(list, e.value)
+	}
+
+	st.Slices = [][]int{list}
+
+	return st
+}
+
+// Restores the checkpoint made by state, and writes the current permutation into dest.
+func (gen *MultiPermutationGenerator[T]) load(st *generatorState) error {
+	if err := st.check(gen.opts, gen.count(), gen.reps, 4, gen.n); err != nil {
+		return err
+	}
+
+	v, p := st.Vars, st.Slices[0]
+
+	if !inRange(v[2:], 0, 1) || !isMultiset(p, gen.reps) {
+		return fmt.Errorf("invalid state data")
+	}
+
+	// the single element case doesn't use the list
+	if !gen.single && !inRange(v[:2], 0, gen.n-1) {
+		return fmt.Errorf("invalid state data")
+	}
+
+	gen.ii, gen.jj = v[0], v[1]
+	gen.first, gen.returned = v[2] == 1, v[3] == 1
+	gen.pos, gen.from, gen.to = st.Pos, st.From, st.To
+	gen.change = st.Change
+
+	switch {
+	case gen.single:
+		if gen.returned {
+			for i := range gen.n {
+				gen.set(i, 0)
+			}
+		}
+	case gen.lex:
+		copy(gen.p, p)
+		gen.fill(0)
+	default:
+		e := gen.h
+
+		for k, x := range p {
+			e.value = x
+
+			if k == gen.ii {
+				gen.i = e
+			}
+
+			if k == gen.jj {
+				gen.j = e
+			}
+
+			e = e.next
+		}
+
+		gen.dump()
+	}
+
+	return nil
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current permutation, and shares only the input with the original.
+func (gen *MultiPermutationGenerator[T]) Clone() *MultiPermutationGenerator[T] {
+	c := *gen
+	c.dest = slices.Clone(gen.dest)
+	c.p = slices.Clone(gen.p)
+
+	if gen.idx != nil {
+		c.idx = any(c.dest).([]int)
+	}
+
+	if gen.h != nil {
+		nodes := make(map[*listElement[int]]*listElement[int], gen.n)
+		c.h = gen.h.clone(nodes)
+		c.i, c.j, c.s, c.t = nodes[gen.i], nodes[gen.j], nodes[gen.s], nodes[gen.t]
+	}
+
+	return &c
+}
+
 // NewMultiPermutationGenerator creates and initializes a new MultiPermutationGenerator.
 // Arguments and returned errors are the same ones from the [MultiPermutationGenerator.Init] method.
-func NewMultiPermutationGenerator[T any](elems []T, reps []int) (*MultiPermutationGenerator[T], error) {
+func NewMultiPermutationGenerator[T any](elems []T, reps []int, opts ...GeneratorOption) (*MultiPermutationGenerator[T], error) {
 	gen := new(MultiPermutationGenerator[T])
-	err := gen.Init(elems, reps)
+	err := gen.Init(elems, reps, opts...)
 
 	if err != nil {
 		return nil, err
@@ -318,12 +910,45 @@ func NewMultiPermutationGenerator[T any](elems []T, reps []int) (*MultiPermutati
 	return gen, nil
 }
 
+// NewMultiPermutationIndexGenerator creates a new MultiPermutationGenerator that produces
+// permutations of a multiset as positions of its distinct elements, i.e. as integers in the
+// range [0, len(reps)), where reps contains the number of repetitions of every element.
+// Unlike [NewMultiPermutationGenerator], it doesn't require the input slice. Returns an error
+// if reps is empty, otherwise the same errors as [MultiPermutationGenerator.Init].
+func NewMultiPermutationIndexGenerator(reps []int, opts ...GeneratorOption) (*MultiPermutationGenerator[int], error) {
+	if len(reps) == 0 {
+		return nil, fmt.Errorf("empty input slice(s)")
+	}
+
+	gen := new(MultiPermutationGenerator[int])
+
+	if err := gen.init(nil, reps, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
 // simple linked list element
 type listElement[T any] struct {
 	value T
 	next  *listElement[T]
 }
 
+// Returns a copy of the list that starts with the element, and records the copy
+// of every element in nodes.
+func (el *listElement[T]) clone(nodes map[*listElement[T]]*listElement[T]) *listElement[T] {
+	head := &listElement[T]{value: el.value}
+	nodes[el] = head
+
+	for e, c := el.next, head; e != nil; e, c = e.next, c.next {
+		c.next = &listElement[T]{value: e.value}
+		nodes[e] = c.next
+	}
+
+	return head
+}
+
 // nth element after
 func (el *listElement[T]) nth(n int) *listElement[T] {
 	if el == nil {
@@ -343,18 +968,19 @@ func (el *listElement[T]) nth(n int) *listElement[T] {
 	return v
 }
 
-// dump list into a dest slice
-func dump[T any](e *listElement[int], items []T, max int, dest []T) {
-	if e == nil {
-		return
-	}
-
-	dest[0] = items[e.value]
+// MultiPermutationsSeq returns an iterator over permutations of a multiset, paired with
+// their rank. The sequence is empty if the arguments are invalid, see
+// [MultiPermutationGenerator.Init] for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func MultiPermutationsSeq[T any](elems []T, reps []int) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewMultiPermutationGenerator(elems, reps)
 
-	v := e.next
+		if err != nil {
+			return
+		}
 
-	for i := 1; i < max && v != nil; i++ {
-		dest[i] = items[v.value]
-		v = v.next
+		gen.All()(yield)
 	}
 }
@@ -5,7 +5,8 @@
 package kombinat
 
 import (
-	// "fmt"
+	"errors"
+	"fmt"
 	"slices"
 	"testing"
 )
@@ -137,6 +138,69 @@ func TestMultiPermutationsCount(t *testing.T) {
 	if MultiPermutationsCount([]int{1, 1, 1}) != PermutationCount(3) {
 		t.Errorf("not equivalent")
 	}
+
+	if c := MultiPermutationsCount([]int{12, 12}); c != 2704156 {
+		t.Errorf("got: %v\n, want: 2704156", c)
+	}
+
+	if _, err := MultiPermutationsCountChecked([]int{10, 20, 30, 40}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("got: %v\n, want: ErrOverflow", err)
+	}
+
+	if c := MultiPermutationsCountBig([]int{2, 1, 3, 2}); c.Int64() != 1680 {
+		t.Errorf("got: %v\n, want: 1680", c)
+	}
+}
+
+func TestMultiPermutationRank(t *testing.T) {
+	for _, v := range _mp_data {
+		v := v
+
+		t.Run(v.name, func(t *testing.T) {
+			want := slices.Clone(v.want)
+
+			slices.SortFunc(want, func(e1, e2 []string) int {
+				return slices.Compare(e1, e2)
+			})
+
+			for r, w := range want {
+				perm, err := MultiPermutationUnrank(v.reps, r)
+
+				if err != nil {
+					t.Fatalf("got error: %v", err)
+				}
+
+				for i, p := range perm {
+					if v.input[p] != w[i] {
+						t.Errorf("not equal at %v, \ngot: %v, \nwant: %v", r, perm, w)
+						break
+					}
+				}
+
+				if rank, err := MultiPermutationRank(v.reps, perm); err != nil || rank != r {
+					t.Errorf("wrong rank for %v, got: %v (%v), want: %v", perm, rank, err, r)
+				}
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := MultiPermutationUnrank([]int{1, 2}, 3); err == nil {
+			t.Errorf("didn't err on rank out of range")
+		}
+		if _, err := MultiPermutationUnrank([]int{1, 0}, 0); err == nil {
+			t.Errorf("didn't err on rep = 0")
+		}
+		if _, err := MultiPermutationRank([]int{1, 2}, []int{0, 1}); err == nil {
+			t.Errorf("didn't err on a short permutation")
+		}
+		if _, err := MultiPermutationRank([]int{1, 2}, []int{0, 0, 1}); err == nil {
+			t.Errorf("didn't err on a permutation not matching reps")
+		}
+		if _, err := MultiPermutationRank([]int{1, 2}, []int{0, 1, 2}); err == nil {
+			t.Errorf("didn't err on position out of range")
+		}
+	})
 }
 
 func TestMultiPermutationGenerator(t *testing.T) {
@@ -232,6 +296,207 @@ func TestMultiPermutationGenerator(t *testing.T) {
 	})
 }
 
+func TestMultiPermutationIndexGenerator(t *testing.T) {
+	reps := []int{2, 1, 2}
+	gen, err := NewMultiPermutationIndexGenerator(reps)
+
+	if err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	want, _ := MultiPermutations(indices(len(reps)), reps)
+	testGenerator[int](t, gen, want)
+
+	gen, _ = NewMultiPermutationIndexGenerator(reps, Reverse())
+
+	if !gen.Prev() || !slices.Equal(gen.Current(), want[len(want)-1]) {
+		t.Errorf("Not at the end with Reverse, got: %v, want: %v", gen.Current(), want[len(want)-1])
+	}
+
+	if _, err := NewMultiPermutationIndexGenerator(nil); err == nil {
+		t.Errorf("Didn't err on empty reps")
+	}
+}
+
+func TestMultiPermutationGeneratorLexicographic(t *testing.T) {
+	items := []string{"A", "B", "C"}
+
+	for _, reps := range [][]int{{1}, {3}, {1, 1}, {2, 1}, {1, 2, 1}, {2, 1, 2}} {
+		elems := items[:len(reps)]
+		want := make([][]string, MultiPermutationsCount(reps))
+
+		for r := range want {
+			positions, _ := MultiPermutationUnrank(reps, r)
+			want[r] = ApplyCopy(positions, elems)
+		}
+
+		gen, err := NewMultiPermutationGenerator(elems, reps, Lexicographic())
+
+		if err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		testGenerator[string](t, gen, want)
+
+		if len(want) > 1 {
+			gen.Reset()
+			testChanges[string](t, gen, elems)
+		}
+
+		testPrev(t, elems, func(opts ...GeneratorOption) reversibleGenerator[string] {
+			gen, _ := NewMultiPermutationGenerator(elems, reps, append(opts, Lexicographic())...)
+			return gen
+		})
+	}
+}
+
+func TestMultiPermutationGeneratorAll(t *testing.T) {
+	gen, _ := NewMultiPermutationGenerator([]string{"A", "B", "C"}, []int{1, 1, 2})
+	want := make([][]string, 0, MultiPermutationsCount([]int{1, 1, 2}))
+
+	for gen.Next() {
+		want = append(want, gen.CurrentCopy())
+	}
+
+	res := make([][]string, 0, len(want))
+
+	for i, p := range gen.All() {
+		if i != len(res) {
+			t.Errorf("wrong rank, got: %v, want: %v", i, len(res))
+		}
+		res = append(res, slices.Clone(p))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("not equal, \ngot: %v, \nwant: %v", res, want)
+	}
+
+	res = res[:0]
+
+	for p := range gen.Values() {
+		res = append(res, slices.Clone(p))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("not equal (values), \ngot: %v, \nwant: %v", res, want)
+	}
+
+	// break + resume
+	for i := range gen.All() {
+		if i == 4 {
+			break
+		}
+	}
+
+	if gen.Next(); slices.Compare(gen.Current(), want[5]) != 0 {
+		t.Errorf("not resumed after break, got: %v, want: %v", gen.Current(), want[5])
+	}
+
+	gen.Reset()
+
+	if gen.Next(); slices.Compare(gen.Current(), want[0]) != 0 {
+		t.Errorf("not reset after break, got: %v, want: %v", gen.Current(), want[0])
+	}
+}
+
+func TestMultiPermutationsSeq(t *testing.T) {
+	for _, v := range _mp_data {
+		v := v
+
+		t.Run(v.name, func(t *testing.T) {
+			res := make([][]string, 0, len(v.want))
+
+			for i, p := range MultiPermutationsSeq(v.input, v.reps) {
+				if i != len(res) {
+					t.Errorf("wrong rank, got: %v, want: %v", i, len(res))
+				}
+				res = append(res, slices.Clone(p))
+			}
+
+			want := slices.Clone(v.want)
+
+			slices.SortFunc(want, func(e1, e2 []string) int {
+				return slices.Compare(e1, e2)
+			})
+			slices.SortFunc(res, func(e1, e2 []string) int {
+				return slices.Compare(e1, e2)
+			})
+
+			if compareSliceOfSlices(res, want) != 0 {
+				t.Errorf("not equal, \ngot: %v, \nwant: %v", res, want)
+			}
+		})
+	}
+
+	for range MultiPermutationsSeq([]string{"A"}, []int{0}) {
+		t.Errorf("didn't return an empty sequence on invalid input")
+	}
+}
+
+func TestMultiPermutationGeneratorSeek(t *testing.T) {
+	table := [][]int{
+		{1}, {3}, {1, 1}, {2, 1}, {1, 2}, {2, 2}, {1, 1, 2}, {2, 1, 2}, {1, 2, 3},
+		{3, 1, 2}, {1, 1, 1, 1}, {2, 1, 3, 2}, {3, 3}, {1, 4}, {2, 2, 2}, {3, 1, 1, 1},
+	}
+
+	for _, reps := range table {
+		reps := reps
+		elems := make([]int, len(reps))
+
+		for i := range elems {
+			elems[i] = i
+		}
+
+		t.Run(fmt.Sprint(reps), func(t *testing.T) {
+			gen, _ := NewMultiPermutationGenerator(elems, reps)
+			want := make([][]int, 0, MultiPermutationsCount(reps))
+
+			for gen.Next() {
+				if gen.Position() != len(want) {
+					t.Errorf("wrong position, got: %v, want: %v", gen.Position(), len(want))
+				}
+				want = append(want, gen.CurrentCopy())
+			}
+
+			for r := range want {
+				if err := gen.Seek(r); err != nil {
+					t.Fatalf("got error: %v", err)
+				}
+
+				if gen.Position() != r-1 {
+					t.Errorf("wrong position after seek, got: %v, want: %v", gen.Position(), r-1)
+				}
+
+				for i := r; i < len(want) && i < r+len(reps)+2; i++ {
+					if !gen.Next() || slices.Compare(gen.Current(), want[i]) != 0 {
+						t.Fatalf("not equal at %v after seek to %v, \ngot: %v, \nwant: %v", i, r, gen.Current(), want[i])
+					}
+				}
+			}
+
+			gen.Seek(len(want) - 1)
+			gen.Next()
+
+			if gen.Next() {
+				t.Errorf("didn't return false on end after seek")
+			}
+			if err := gen.Seek(len(want)); err != nil || gen.Next() {
+				t.Errorf("didn't seek to end")
+			}
+			if gen.Seek(len(want)+1) == nil || gen.Seek(-1) == nil {
+				t.Errorf("didn't err on rank out of range")
+			}
+		})
+	}
+}
+
+func TestMultiPermutationGeneratorShard(t *testing.T) {
+	for _, v := range _mp_data {
+		gen, _ := NewMultiPermutationGenerator(v.input, v.reps)
+		testShards[string](t, gen)
+	}
+}
+
 func BenchmarkMultiPermutations(b *testing.B) {
 	table := []struct {
 		name  string
@@ -0,0 +1,50 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+// GeneratorOption changes the order in which a generator produces its results.
+// Not every generator supports every option, see Init methods of generators for details.
+type GeneratorOption func(*genOptions)
+
+type genOptions struct {
+	gray, lex, reverse bool
+}
+
+// Gray makes the generator produce its results in a Gray code order, where
+// consecutive results differ as little as possible.
+func Gray() GeneratorOption {
+	return func(o *genOptions) {
+		o.gray = true
+	}
+}
+
+// Lexicographic makes the generator produce its results in the lexicographic order of
+// positions of elements in the input slice, which is the order used by the Rank and
+// Unrank functions. Generators whose results are already in that order accept it as well.
+func Lexicographic() GeneratorOption {
+	return func(o *genOptions) {
+		o.lex = true
+	}
+}
+
+// Reverse makes the generator start at the end of its sequence instead of the beginning,
+// so that it can be walked backwards by calling Prev. [Generator.Reset] and Range
+// move such a generator to the end as well.
+func Reverse() GeneratorOption {
+	return func(o *genOptions) {
+		o.reverse = true
+	}
+}
+
+// Returns the options set by opts.
+func applyOptions(opts []GeneratorOption) genOptions {
+	o := genOptions{}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ParallelForEach calls fn for every item in the sequence of generators created by the
+// factory, splitting the sequence between the given number of workers that run in
+// separate goroutines. If workers < 1, runtime.GOMAXPROCS(0) workers are started.
+//
+// Every worker creates its own generator, so the factory has to return a new one on
+// every call, and limits it to its part of the sequence with [SeekableGenerator.Shard].
+// Generators with the [Reverse] option walk their parts backwards.
+// The slice passed to fn is the one returned by [Generator.Current], which is reused
+// by the generator, so fn has to clone it to modify it or to keep it after returning.
+//
+// Processing stops on the first error returned by the factory or fn, or when ctx is
+// cancelled. Returns errors from all workers joined with [errors.Join], along with
+// the error of ctx if it was cancelled.
+func ParallelForEach[T any](ctx context.Context, factory func() (SeekableGenerator[T], error), workers int, fn func(worker int, item []T) error) error {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, workers+1)
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := forEachInShard(wctx, factory, w, workers, fn); err != nil {
+				errs[w] = err
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+	errs[workers] = ctx.Err()
+
+	return errors.Join(errs...)
+}
+
+// Processes the items in the shard w out of total until the end or cancellation.
+func forEachInShard[T any](ctx context.Context, factory func() (SeekableGenerator[T], error), w, total int, fn func(int, []T) error) error {
+	gen, err := factory()
+
+	if err != nil {
+		return err
+	}
+
+	if err := gen.Shard(w, total); err != nil {
+		return err
+	}
+
+	done := ctx.Done()
+	step := gen.Next
+	ok := step()
+
+	// a generator with the Reverse option starts at the end of its shard,
+	// so it's walked backwards
+	if r, isReversible := gen.(reversibleGenerator[T]); !ok && isReversible {
+		step = r.Prev
+		ok = step()
+	}
+
+	for ; ok; ok = step() {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		if err := fn(w, gen.Current()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
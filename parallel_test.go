@@ -0,0 +1,152 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelForEach(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6}
+	want, _ := Permutations(items)
+
+	slices.SortFunc(want, func(e1, e2 []int) int {
+		return slices.Compare(e1, e2)
+	})
+
+	for _, opts := range [][]GeneratorOption{nil, {Reverse()}} {
+		factory := func() (SeekableGenerator[int], error) {
+			return NewPermutationGenerator(items, opts...)
+		}
+
+		for _, workers := range []int{0, 1, 3, 8} {
+			res := make([][]int, 0, len(want))
+			mu := sync.Mutex{}
+
+			err := ParallelForEach(context.Background(), factory, workers, func(w int, p []int) error {
+				if w < 0 || (workers > 0 && w >= workers) {
+					t.Errorf("Wrong worker index %d", w)
+				}
+
+				mu.Lock()
+				res = append(res, slices.Clone(p))
+				mu.Unlock()
+
+				return nil
+			})
+
+			if err != nil {
+				t.Errorf("Error'd with: %v", err)
+			}
+
+			slices.SortFunc(res, func(e1, e2 []int) int {
+				return slices.Compare(e1, e2)
+			})
+
+			if compareSliceOfSlices(res, want) != 0 {
+				t.Errorf("Not equal with %d workers and %d options, got %d items, want %d", workers, len(opts), len(res), len(want))
+			}
+		}
+	}
+}
+
+func TestParallelForEachErrors(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	factory := func() (SeekableGenerator[int], error) {
+		return NewPermutationGenerator(items)
+	}
+
+	t.Run("fn", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := atomic.Int64{}
+
+		err := ParallelForEach(context.Background(), factory, 4, func(w int, p []int) error {
+			if calls.Add(1) == 100 {
+				return errStop
+			}
+			return nil
+		})
+
+		if !errors.Is(err, errStop) {
+			t.Errorf("Wrong error, got: %v", err)
+		}
+		if c := calls.Load(); c >= int64(PermutationCount(len(items))) {
+			t.Errorf("Didn't stop on error, %d calls", c)
+		}
+	})
+
+	t.Run("factory", func(t *testing.T) {
+		err := ParallelForEach(context.Background(), func() (SeekableGenerator[int], error) {
+			return NewPermutationGenerator([]int{})
+		}, 2, func(w int, p []int) error {
+			t.Errorf("Called fn after a factory error")
+			return nil
+		})
+
+		if err == nil {
+			t.Errorf("Didn't err on factory error")
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := atomic.Int64{}
+
+		err := ParallelForEach(ctx, factory, 4, func(w int, p []int) error {
+			if calls.Add(1) == 100 {
+				cancel()
+			}
+			return nil
+		})
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Wrong error, got: %v", err)
+		}
+		if c := calls.Load(); c >= int64(PermutationCount(len(items))) {
+			t.Errorf("Didn't stop on cancellation, %d calls", c)
+		}
+	})
+}
+
+func TestParallelForEachSubsets(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	want, _ := Subsets(0, len(items), items)
+
+	factory := func() (SeekableGenerator[int], error) {
+		return NewSubsetGenerator(0, len(items), items)
+	}
+
+	for _, workers := range []int{1, 3, 8} {
+		res := make([][]int, 0, len(want))
+		mu := sync.Mutex{}
+
+		err := ParallelForEach(context.Background(), factory, workers, func(w int, s []int) error {
+			mu.Lock()
+			res = append(res, slices.Clone(s))
+			mu.Unlock()
+
+			return nil
+		})
+
+		if err != nil {
+			t.Errorf("Error'd with: %v", err)
+		}
+
+		// subsets of different sizes are sorted by their size first
+		slices.SortFunc(res, func(e1, e2 []int) int {
+			return cmp.Or(cmp.Compare(len(e1), len(e2)), slices.Compare(e1, e2))
+		})
+
+		if compareSliceOfSlices(res, want) != 0 {
+			t.Errorf("Not equal with %d workers, \ngot: %v, \nwant: %v", workers, res, want)
+		}
+	}
+}
@@ -5,7 +5,10 @@
 package kombinat
 
 import (
+	"encoding/json"
 	"fmt"
+	"iter"
+	"math/big"
 	"slices"
 )
 
@@ -17,77 +20,298 @@ func PermutationCount(n int) int {
 	return Fac(n)
 }
 
+// PermutationCountChecked returns the number of permutations of n elements,
+// or [ErrOverflow] if it doesn't fit in an int.
+func PermutationCountChecked(n int) (int, error) {
+	if n <= 0 {
+		return 0, nil
+	}
+
+	return FacChecked(n)
+}
+
+// PermutationCountBig returns the number of permutations of n elements as [big.Int].
+func PermutationCountBig(n int) *big.Int {
+	if n <= 0 {
+		return big.NewInt(0)
+	}
+
+	return FacBig(n)
+}
+
+// PermutationRank returns the rank of a permutation in the lexicographic order of
+// permutations of len(positions) elements, calculated from its [Lehmer code].
+// The permutation is given as positions of elements in the input slice, that is,
+// as a permutation of numbers in the range [0, len(positions)).
+//
+// Returns an error if positions is empty or is not a permutation.
+//
+// [Lehmer code]: https://en.wikipedia.org/wiki/Lehmer_code
+func PermutationRank(positions []int) (int, error) {
+	n := len(positions)
+
+	if n == 0 {
+		return 0, fmt.Errorf("input slice is nil or empty")
+	}
+
+	seen := make([]bool, n)
+	rank := 0
+
+	for i, p := range positions {
+		if p < 0 || p >= n || seen[p] {
+			return 0, fmt.Errorf("positions are not a permutation")
+		}
+
+		seen[p] = true
+		smaller := 0
+
+		for _, q := range positions[i+1:] {
+			if q < p {
+				smaller++
+			}
+		}
+
+		rank += smaller * Fac(n-i-1)
+	}
+
+	return rank, nil
+}
+
+// PermutationUnrank returns positions of the permutation at the given rank in the
+// lexicographic order of permutations of n elements. It's the inverse of [PermutationRank].
+//
+// Returns an error if n < 1 or if the rank is not in the range [0, PermutationCount(n)).
+func PermutationUnrank(n, rank int) ([]int, error) {
+	switch {
+	case n <= 0:
+		return nil, fmt.Errorf("n must be >= 1")
+	case rank < 0 || rank >= PermutationCount(n):
+		return nil, fmt.Errorf("rank out of range")
+	}
+
+	positions := make([]int, n)
+	permutationUnrank(n, rank, positions)
+
+	return positions, nil
+}
+
+// Writes positions of the permutation at the lexicographic rank into dest
+// by decoding the rank into a factorial number system.
+func permutationUnrank(n, rank int, dest []int) {
+	for i := range dest[:n] {
+		dest[i] = i
+	}
+
+	for i := 0; i < n-1; i++ {
+		f := saturate(FacChecked(n - i - 1))
+		d := rank / f
+		rank %= f
+
+		// move the d-th remaining position to i
+		p := dest[i+d]
+		copy(dest[i+1:i+d+1], dest[i:i+d])
+		dest[i] = p
+	}
+}
+
+// Rearranges p into the next permutation in the lexicographic order, and returns the
+// first changed position, or -1 if p is the last permutation. Values in p can repeat.
+func nextPermutation(p []int) int {
+	i := len(p) - 2
+
+	for i >= 0 && p[i] >= p[i+1] {
+		i--
+	}
+
+	if i < 0 {
+		return -1
+	}
+
+	j := len(p) - 1
+
+	for p[j] <= p[i] {
+		j--
+	}
+
+	p[i], p[j] = p[j], p[i]
+	slices.Reverse(p[i+1:])
+
+	return i
+}
+
+// Rearranges p into the previous permutation in the lexicographic order, and returns the
+// first changed position, or -1 if p is the first permutation. Values in p can repeat.
+func prevPermutation(p []int) int {
+	i := len(p) - 2
+
+	for i >= 0 && p[i] <= p[i+1] {
+		i--
+	}
+
+	if i < 0 {
+		return -1
+	}
+
+	j := len(p) - 1
+
+	for p[j] >= p[i] {
+		j--
+	}
+
+	p[i], p[j] = p[j], p[i]
+	slices.Reverse(p[i+1:])
+
+	return i
+}
+
+// Returns the change of a permutation of n elements made by nextPermutation or
+// prevPermutation, where i is the first changed position.
+func permutationChange(i, n int) Change {
+	if i == n-2 {
+		return Change{Kind: ChangeSwap, I: i, J: i + 1}
+	}
+
+	return Change{Kind: ChangeRange, I: i, J: n}
+}
+
 // Permutations without repetition by using non-recursive [Heap's algorithm].
 // Returns an error if elems is empty or nil.
 //
 // [Heap's algorithm]: https://en.wikipedia.org/wiki/Heap's_algorithm
 func Permutations[T any](elems []T) ([][]T, error) {
-	n := len(elems)
+	return PermutationsWithLimit(elems)
+}
 
-	if n == 0 {
-		return nil, fmt.Errorf("input slice is nil or empty")
+// PermutationsWithLimit is like [Permutations], but returns [TooManyResultsError]
+// before allocating the results if there are more of them than allowed by opts.
+func PermutationsWithLimit[T any](elems []T, opts ...LimitOption) ([][]T, error) {
+	t, err := PermutationsTable(elems, opts...)
+
+	if err != nil {
+		return nil, err
 	}
 
-	count := PermutationCount(n)
-	res := make([][]T, 0, count)
+	return t.Rows(), nil
+}
 
-	c := make([]int, n)
-	a := slices.Clone(elems)
-	res = append(res, slices.Clone(elems))
+// PermutationsTable is like [PermutationsWithLimit], but stores the results in a [Table].
+func PermutationsTable[T any](elems []T, opts ...LimitOption) (*Table[T], error) {
+	gen, err := NewPermutationGenerator(elems)
 
-	i := 1
-	for i < n {
-		if c[i] < i {
-			if i%2 == 0 {
-				s := a[0]
-				a[0] = a[i]
-				a[i] = s
-			} else {
-				s := a[c[i]]
-				a[c[i]] = a[i]
-				a[i] = s
-			}
-			res = append(res, slices.Clone(a))
-			c[i]++
-			i = 1
-		} else {
-			c[i] = 0
-			i++
-		}
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(elems)
+	count, err := PermutationCountChecked(n)
+
+	if err := checkLimit[T](count, err, n, opts, func() *big.Int { return PermutationCountBig(n) }); err != nil {
+		return nil, err
 	}
 
-	return res, nil
+	return fillTable(gen, count, n), nil
 }
 
 // PermutationGenerator implements a [Generator] interface for generating
 // permutations by an algorithm described in [Permutations].
 type PermutationGenerator[T any] struct {
-	n, i     int
-	c        []int
-	a, elems []T
+	n, i, pos, from, to int
+	c, p, inv, dir, idx []int
+	a, elems            []T
+	opts                []GeneratorOption
+	gray, lex, reverse  bool
+	change              Change
 }
 
 // Init initializes a generator of permutations.
 // Returns an error if input slice is nil or empty.
-func (gen *PermutationGenerator[T]) Init(elems []T) error {
+//
+// Supported options are [Gray], [Lexicographic] and [Reverse]. With the [Gray] option,
+// permutations are produced by the [Steinhaus–Johnson–Trotter algorithm] instead, where
+// consecutive permutations differ by a swap of two adjacent elements. With the
+// [Lexicographic] option, the generator doesn't use Heap's algorithm, so consecutive
+// permutations can differ in more than two elements. [Gray] and [Lexicographic]
+// can't be combined.
+//
+// [Steinhaus–Johnson–Trotter algorithm]: https://en.wikipedia.org/wiki/Steinhaus%E2%80%93Johnson%E2%80%93Trotter_algorithm
+func (gen *PermutationGenerator[T]) Init(elems []T, opts ...GeneratorOption) error {
 	if len(elems) == 0 {
 		return fmt.Errorf("input slice is nil or empty")
 	}
 
-	gen.n = len(elems)
+	return gen.init(len(elems), elems, opts)
+}
+
+// Initializes the generator for permutations of n elements. If elems is nil, the generator
+// is in index mode and produces positions instead of elements, which requires T to be int.
+func (gen *PermutationGenerator[T]) init(n int, elems []T, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if o.gray && o.lex {
+		return fmt.Errorf("gray code and lexicographic order can't be combined")
+	}
+
+	gen.n = n
 	gen.elems = elems
 	gen.c = make([]int, gen.n)
-	gen.a = slices.Clone(elems)
+	gen.a = make([]T, gen.n)
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.a).([]int)
+	}
+
+	for i := range gen.n {
+		gen.set(i, i)
+	}
+
 	gen.i = 0
+	gen.pos = -1
+	gen.change = Change{}
+	gen.opts = opts
+	gen.gray = o.gray
+	gen.lex = o.lex
+	gen.reverse = o.reverse
+
+	gen.p = indices(gen.n)
+
+	if gen.gray {
+		// every element starts on the right of the smaller ones and moves to the left
+		gen.inv = indices(gen.n)
+		gen.dir = slices.Repeat([]int{-1}, gen.n)
+	}
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.reverse {
+		gen.end()
+	}
 
 	return nil
 }
 
-// Reset resets the generator to the beginning of the sequence.
+// Returns the number of permutations in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *PermutationGenerator[T]) count() int {
+	return saturate(PermutationCountChecked(gen.n))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
 func (gen *PermutationGenerator[T]) Reset() {
-	s := gen.a
-	gen.Init(gen.elems)
+	s, from, to := gen.a, gen.from, gen.to
+	gen.init(gen.n, gen.elems, gen.opts)
 	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
 }
 
 // Current returns the internal slice that holds the current permutation.
@@ -115,6 +339,10 @@ func (gen *PermutationGenerator[T]) SetDest(dest []T) error {
 	copy(dest, gen.a)
 	gen.a = dest
 
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
 	return nil
 }
 
@@ -122,12 +350,48 @@ func (gen *PermutationGenerator[T]) SetDest(dest []T) error {
 // there are no more permutations available.
 func (gen *PermutationGenerator[T]) Next() bool {
 
-	if gen.n == 0 {
+	if gen.n == 0 || gen.pos+1 >= gen.to {
 		return false
 	}
 
 	if gen.i == 0 {
 		gen.i = 1
+		gen.change = Change{Kind: ChangeAll}
+		gen.pos++
+		return true
+	}
+
+	if gen.lex {
+		i := nextPermutation(gen.p)
+
+		if i < 0 {
+			return false
+		}
+
+		gen.fill(i)
+		gen.change = permutationChange(i, gen.n)
+		gen.pos++
+		return true
+	}
+
+	if gen.gray {
+		// the counters hold the number of moves of each element in its current sweep,
+		// so the largest element that hasn't finished its sweep moves next
+		j := gen.n - 1
+
+		for j > 0 && gen.c[j] == j {
+			gen.c[j] = 0
+			gen.dir[j] = -gen.dir[j]
+			j--
+		}
+
+		if j == 0 {
+			return false
+		}
+
+		gen.c[j]++
+		gen.move(j, gen.dir[j])
+		gen.pos++
 		return true
 	}
 
@@ -141,13 +405,11 @@ func (gen *PermutationGenerator[T]) Next() bool {
 	}
 
 	if gen.i%2 == 0 {
-		s := gen.a[0]
-		gen.a[0] = gen.a[gen.i]
-		gen.a[gen.i] = s
+		gen.swap(0, gen.i)
+		gen.change = Change{Kind: ChangeSwap, I: 0, J: gen.i}
 	} else {
-		s := gen.a[gen.c[gen.i]]
-		gen.a[gen.c[gen.i]] = gen.a[gen.i]
-		gen.a[gen.i] = s
+		gen.swap(gen.c[gen.i], gen.i)
+		gen.change = Change{Kind: ChangeSwap, I: gen.c[gen.i], J: gen.i}
 	}
 
 	gen.c[gen.i]++
@@ -156,14 +418,441 @@ func (gen *PermutationGenerator[T]) Next() bool {
 		gen.i = 1
 	}
 
+	gen.pos++
+	return true
+}
+
+// Prev produces the permutation before the current one in the sequence of the generator.
+// If it returns false, there are no previous permutations available in the range.
+// It undoes the last step of the algorithm, so it also differs from the next
+// permutation by a swap of two elements, except right after [PermutationGenerator.Seek]
+// or at the end of the range, when it seeks to the previous permutation instead.
+func (gen *PermutationGenerator[T]) Prev() bool {
+	if gen.n == 0 {
+		return false
+	}
+
+	if gen.i != 1 || gen.change.Kind == ChangeNone {
+		return prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	if gen.pos-1 < gen.from {
+		return false
+	}
+
+	if gen.lex {
+		i := prevPermutation(gen.p)
+		gen.fill(i)
+		gen.change = permutationChange(i, gen.n)
+		gen.pos--
+
+		return true
+	}
+
+	if gen.gray {
+		j := gen.n - 1
+
+		for gen.c[j] == 0 {
+			gen.c[j] = j
+			gen.dir[j] = -gen.dir[j]
+			j--
+		}
+
+		gen.c[j]--
+		gen.move(j, -gen.dir[j])
+		gen.pos--
+
+		return true
+	}
+
+	// the counters hold the position in the factorial number system, so the last
+	// step incremented the lowest non-zero one and reset all below it
+	i := 1
+
+	for gen.c[i] == 0 {
+		gen.c[i] = i
+		i++
+	}
+
+	gen.c[i]--
+
+	if i%2 == 0 {
+		gen.swap(0, i)
+		gen.change = Change{Kind: ChangeSwap, I: 0, J: i}
+	} else {
+		gen.swap(gen.c[i], i)
+		gen.change = Change{Kind: ChangeSwap, I: gen.c[i], J: i}
+	}
+
+	gen.pos--
+
 	return true
 }
 
+// Writes elements at positions in p into the current permutation, starting from the position i.
+func (gen *PermutationGenerator[T]) fill(i int) {
+	for ; i < gen.n; i++ {
+		gen.set(i, gen.p[i])
+	}
+}
+
+// Writes the element at position x into the position i of the current permutation.
+func (gen *PermutationGenerator[T]) set(i, x int) {
+	if gen.idx != nil {
+		gen.idx[i] = x
+	} else {
+		gen.a[i] = gen.elems[x]
+	}
+}
+
+// Swaps elements at positions i and j of the current permutation, and their positions in p.
+func (gen *PermutationGenerator[T]) swap(i, j int) {
+	gen.p[i], gen.p[j] = gen.p[j], gen.p[i]
+	gen.a[i], gen.a[j] = gen.a[j], gen.a[i]
+}
+
+// Swaps the element at position x in the input with its neighbour in the direction d.
+func (gen *PermutationGenerator[T]) move(x, d int) {
+	i := gen.inv[x]
+	j := i + d
+
+	gen.p[i], gen.p[j] = gen.p[j], gen.p[i]
+	gen.a[i], gen.a[j] = gen.a[j], gen.a[i]
+	gen.inv[gen.p[i]], gen.inv[gen.p[j]] = i, j
+	i = min(i, j)
+	gen.change = Change{Kind: ChangeSwap, I: i, J: i + 1}
+}
+
+// LastChange returns the change of the current permutation made by the last call to
+// [PermutationGenerator.Next]. Every permutation after the first
+// one differs from the previous one by a swap of two elements ([ChangeSwap]).
+// With the [Gray] option, the swapped elements are always adjacent, so J is I+1.
+// With the [Lexicographic] option, permutations differ in elements from some position
+// to the end ([ChangeRange]), or by a swap of the last two elements.
+func (gen *PermutationGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current permutation in the sequence of the generator,
+// or -1 if [PermutationGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [PermutationGenerator.Prev] is called.
+func (gen *PermutationGenerator[T]) Position() int {
+	return gen.pos
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [PermutationGenerator.Next] produces the permutation
+// at that rank, and [PermutationGenerator.Prev]
+// the one before it. Seeking to the end of the range set by [PermutationGenerator.Range]
+// (PermutationCount(n) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *PermutationGenerator[T]) Seek(rank int) error {
+	count := gen.count()
+
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.pos = rank - 1
+	gen.i = 0
+
+	if rank == count {
+		rank--
+		gen.i = gen.n
+	}
+
+	if gen.lex {
+		permutationUnrank(gen.n, rank, gen.p)
+		gen.fill(0)
+
+		return nil
+	}
+
+	if gen.gray {
+		gen.unrankGray(rank)
+		gen.fill(0)
+
+		return nil
+	}
+
+	for i := range gen.p {
+		gen.p[i] = i
+	}
+
+	// the counters hold the rank in the factorial number system
+	for i := 1; i < gen.n; i++ {
+		gen.c[i] = rank % (i + 1)
+		rank /= i + 1
+	}
+
+	// replay the outermost loops of the algorithm, skipping over
+	// the complete runs of the inner ones
+	for i := gen.n - 1; i > 0; i-- {
+		for j := 0; j < gen.c[i]; j++ {
+			heapRun(gen.p, i)
+
+			if i%2 == 0 {
+				gen.p[0], gen.p[i] = gen.p[i], gen.p[0]
+			} else {
+				gen.p[j], gen.p[i] = gen.p[i], gen.p[j]
+			}
+		}
+	}
+
+	gen.fill(0)
+
+	if gen.i == gen.n {
+		clear(gen.c)
+	}
+
+	return nil
+}
+
+// Range limits the generator to the permutations with ranks in the range [from, to)
+// and moves it to the rank from. [PermutationGenerator.Reset] preserves the range, and
+// [PermutationGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, PermutationCount(n)].
+func (gen *PermutationGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Sets the state of the Steinhaus–Johnson–Trotter algorithm to the permutation at the rank.
+// The permutation of the first j elements at rank r is the one of the first j-1 elements
+// at rank r/j, with the element j-1 inserted at a position given by r%j, counted from the
+// right when the rank r/j is even and from the left when it's odd.
+func (gen *PermutationGenerator[T]) unrankGray(rank int) {
+	for j := gen.n - 1; j > 0; j-- {
+		gen.c[j] = rank % (j + 1)
+		rank /= j + 1
+		gen.dir[j] = -1
+
+		if rank%2 == 1 {
+			gen.dir[j] = 1
+		}
+	}
+
+	gen.p = gen.p[:1]
+	gen.p[0] = 0
+
+	for j := 1; j < gen.n; j++ {
+		i := gen.c[j]
+
+		if gen.dir[j] < 0 {
+			i = j - i
+		}
+
+		gen.p = slices.Insert(gen.p, i, j)
+	}
+
+	for i, x := range gen.p {
+		gen.inv[x] = i
+	}
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *PermutationGenerator[T]) end() {
+	gen.pos = gen.to
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *PermutationGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// Applies the net effect of a complete run of Heap's algorithm over the first k
+// elements of the slice.
+func heapRun[T any](a []T, k int) {
+	switch {
+	case k < 2:
+	case k == 2:
+		a[0], a[1] = a[1], a[0]
+	case k%2 == 1:
+		a[0], a[k-1] = a[k-1], a[0]
+	default:
+		// [a0, a1, ..., ak-1] -> [ak-3, ak-2, a1, ..., ak-4, ak-1, a0]
+		a0, a3, a2, a1 := a[0], a[k-3], a[k-2], a[k-1]
+		copy(a[2:k-2], a[1:k-3])
+		a[0], a[1], a[k-2], a[k-1] = a3, a2, a1, a0
+	}
+}
+
+// All returns an iterator over all permutations paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [PermutationGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded permutation and can be resumed with [PermutationGenerator.Next] or restarted with
+// [PermutationGenerator.Reset].
+func (gen *PermutationGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [PermutationGenerator.All], but it yields only the permutations.
+func (gen *PermutationGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following permutations, as if by calling
+// [PermutationGenerator.Next] and [PermutationGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of permutations written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *PermutationGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [PermutationGenerator.NextBatch], but writes the permutations one after another
+// into dst, where every permutation takes n elements. Writes as many permutations as fit into dst.
+func (gen *PermutationGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.n)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler]. It encodes the state of the algorithm,
+// the position of the generator, its range and options, and the number of elements, but not
+// the elements, so the state can be restored only into a generator initialized with the
+// same input and options.
+func (gen *PermutationGenerator[T]) MarshalBinary() ([]byte, error) {
+	return gen.state().MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]. It restores the state encoded by
+// [PermutationGenerator.MarshalBinary] into the generator, which must be initialized with the
+// same input and options, so that [PermutationGenerator.Current] returns the same permutation, and the following
+// call to [PermutationGenerator.Next] continues the sequence. Returns an error if the state is
+// invalid or if it doesn't match the generator.
+func (gen *PermutationGenerator[T]) UnmarshalBinary(data []byte) error {
+	st := new(generatorState)
+
+	if err := st.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// MarshalJSON implements [json.Marshaler]. It's the JSON equivalent of [PermutationGenerator.MarshalBinary].
+func (gen *PermutationGenerator[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gen.state())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It's the JSON equivalent of [PermutationGenerator.UnmarshalBinary].
+func (gen *PermutationGenerator[T]) UnmarshalJSON(data []byte) error {
+	st := new(generatorState)
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// Returns the checkpoint of the generator. Its variable is i of Heap's algorithm, and its
+// slices are the counters c and the positions p of elements in the current permutation,
+// followed by the directions of elements with the [Gray] option.
+func (gen *PermutationGenerator[T]) state() *generatorState {
+	st := newState(gen.opts, gen.pos, gen.from, gen.to, gen.change, gen.n)
+	st.Vars = []int{gen.i}
+	st.Slices = [][]int{slices.Clone(gen.c), slices.Clone(gen.p)}
+
+	if gen.gray {
+		st.Slices = append(st.Slices, slices.Clone(gen.dir))
+	}
+
+	return st
+}
+
+// Restores the checkpoint made by state, and writes the current permutation into dest.
+func (gen *PermutationGenerator[T]) load(st *generatorState) error {
+	lens := []int{gen.n, gen.n}
+
+	if gen.gray {
+		lens = append(lens, gen.n)
+	}
+
+	if err := st.check(gen.opts, gen.count(), []int{gen.n}, 1, lens...); err != nil {
+		return err
+	}
+
+	c, p := st.Slices[0], st.Slices[1]
+
+	if st.Vars[0] < 0 || st.Vars[0] > gen.n || !isMultiset(p, slices.Repeat([]int{1}, gen.n)) {
+		return fmt.Errorf("invalid state data")
+	}
+
+	for j, x := range c {
+		if x < 0 || x > j {
+			return fmt.Errorf("invalid state data")
+		}
+	}
+
+	if gen.gray {
+		for _, d := range st.Slices[2] {
+			if d != -1 && d != 1 {
+				return fmt.Errorf("invalid state data")
+			}
+		}
+
+		copy(gen.dir, st.Slices[2])
+
+		for i, x := range p {
+			gen.inv[x] = i
+		}
+	}
+
+	gen.i = st.Vars[0]
+	gen.pos, gen.from, gen.to = st.Pos, st.From, st.To
+	gen.change = st.Change
+	copy(gen.c, c)
+	copy(gen.p, p)
+	gen.fill(0)
+
+	return nil
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current permutation, and shares only the input with the original.
+func (gen *PermutationGenerator[T]) Clone() *PermutationGenerator[T] {
+	c := *gen
+	c.c = slices.Clone(gen.c)
+	c.p = slices.Clone(gen.p)
+	c.inv = slices.Clone(gen.inv)
+	c.dir = slices.Clone(gen.dir)
+	c.a = slices.Clone(gen.a)
+
+	if gen.idx != nil {
+		c.idx = any(c.a).([]int)
+	}
+
+	return &c
+}
+
 // NewPermutationGenerator creates and initializes a new PermutationGenerator.
 // Arguments and returned errors are the same ones from the [PermutationGenerator.Init] method.
-func NewPermutationGenerator[T any](elems []T) (*PermutationGenerator[T], error) {
+func NewPermutationGenerator[T any](elems []T, opts ...GeneratorOption) (*PermutationGenerator[T], error) {
 	gen := new(PermutationGenerator[T])
-	err := gen.Init(elems)
+	err := gen.Init(elems, opts...)
 
 	if err != nil {
 		return nil, err
@@ -171,3 +860,37 @@ func NewPermutationGenerator[T any](elems []T) (*PermutationGenerator[T], error)
 
 	return gen, nil
 }
+
+// NewPermutationIndexGenerator creates a new PermutationGenerator that produces permutations
+// of positions of elements in an implicit input of size n, i.e. of integers in the range [0, n),
+// without requiring the input slice. Returns an error if n < 1, or if the options can't be
+// applied, see [PermutationGenerator.Init].
+func NewPermutationIndexGenerator(n int, opts ...GeneratorOption) (*PermutationGenerator[int], error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be >= 1")
+	}
+
+	gen := new(PermutationGenerator[int])
+
+	if err := gen.init(n, nil, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// PermutationsSeq returns an iterator over permutations of elements in the elems slice,
+// paired with their rank. The sequence is empty if elems is nil or empty.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func PermutationsSeq[T any](elems []T) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewPermutationGenerator(elems)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
@@ -5,6 +5,7 @@
 package kombinat
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"testing"
@@ -45,6 +46,66 @@ var (
 	}
 )
 
+func TestPermutationCount(t *testing.T) {
+	if n := PermutationCount(5); n != 120 {
+		t.Errorf("Want 120, got %v", n)
+	}
+	if n, err := PermutationCountChecked(20); err != nil || n != 2432902008176640000 {
+		t.Errorf("Want 2432902008176640000, got %v, %v", n, err)
+	}
+	if _, err := PermutationCountChecked(21); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Want ErrOverflow, got %v", err)
+	}
+	if n := PermutationCountBig(21).String(); n != "51090942171709440000" {
+		t.Errorf("Want 51090942171709440000, got %v", n)
+	}
+}
+
+func TestPermutationRank(t *testing.T) {
+	want := _perm_table[4]
+
+	for r, w := range want {
+		positions, err := PermutationUnrank(4, r)
+
+		if err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		for i, p := range positions {
+			if _perm_items[p] != w[i] {
+				t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", r, positions, w)
+				break
+			}
+		}
+
+		if rank, err := PermutationRank(positions); err != nil || rank != r {
+			t.Errorf("Wrong rank for %v, got: %v (%v), want: %v", positions, rank, err, r)
+		}
+	}
+
+	if positions, _ := PermutationUnrank(10, PermutationCount(10)-1); slices.Compare(positions, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}) != 0 {
+		t.Errorf("Wrong last permutation: %v", positions)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := PermutationUnrank(4, 24); err == nil {
+			t.Errorf("Didn't err on rank out of range")
+		}
+		if _, err := PermutationUnrank(0, 0); err == nil {
+			t.Errorf("Didn't err on n = 0")
+		}
+		if _, err := PermutationRank([]int{0, 0}); err == nil {
+			t.Errorf("Didn't err on repeated positions")
+		}
+		if _, err := PermutationRank([]int{0, 2}); err == nil {
+			t.Errorf("Didn't err on position out of range")
+		}
+		if _, err := PermutationRank(nil); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+	})
+}
+
 func TestPermutations(t *testing.T) {
 	for i, want := range _perm_table {
 		i := i
@@ -131,6 +192,253 @@ func TestPermutationGenerator(t *testing.T) {
 	}
 }
 
+func TestPermutationIndexGenerator(t *testing.T) {
+	for n := 1; n <= 4; n++ {
+		gen, err := NewPermutationIndexGenerator(n)
+
+		if err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		want, _ := Permutations(indices(n))
+		testGenerator[int](t, gen, want)
+	}
+
+	if _, err := NewPermutationIndexGenerator(0); err == nil {
+		t.Errorf("Didn't err on n = 0")
+	}
+}
+
+func TestPermutationGeneratorLexicographic(t *testing.T) {
+	items := []string{"A", "B", "C", "D"}
+
+	for n := 1; n <= len(items); n++ {
+		want := make([][]string, PermutationCount(n))
+
+		for r := range want {
+			positions, _ := PermutationUnrank(n, r)
+			want[r] = ApplyCopy(positions, items)
+		}
+
+		gen, err := NewPermutationGenerator(items[:n], Lexicographic())
+
+		if err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		testGenerator[string](t, gen, want)
+
+		if len(want) > 1 {
+			gen.Reset()
+			testChanges[string](t, gen, items[:n])
+		}
+
+		testPrev(t, items[:n], func(opts ...GeneratorOption) reversibleGenerator[string] {
+			gen, _ := NewPermutationGenerator(items[:n], append(opts, Lexicographic())...)
+			return gen
+		})
+	}
+}
+
+// Returns permutations of elems in the order of the Steinhaus–Johnson–Trotter algorithm,
+// by inserting the last element into every permutation of the others, alternately from
+// the right and from the left.
+func plainChanges[T any](elems []T) [][]T {
+	n := len(elems)
+
+	if n == 1 {
+		return [][]T{slices.Clone(elems)}
+	}
+
+	res := make([][]T, 0, PermutationCount(n))
+
+	for r, p := range plainChanges(elems[:n-1]) {
+		for i := range n {
+			if r%2 == 0 {
+				i = n - 1 - i
+			}
+			res = append(res, slices.Insert(slices.Clone(p), i, elems[n-1]))
+		}
+	}
+
+	return res
+}
+
+func TestPermutationGeneratorGray(t *testing.T) {
+	items := []string{"A", "B", "C", "D", "E"}
+
+	want := [][]string{{"A", "B", "C"}, {"A", "C", "B"}, {"C", "A", "B"}, {"C", "B", "A"}, {"B", "C", "A"}, {"B", "A", "C"}}
+
+	if res := plainChanges(items[:3]); compareSliceOfSlices(res, want) != 0 {
+		t.Fatalf("Unexpected reference, \ngot: %v, \nwant: %v", res, want)
+	}
+
+	for n := 1; n <= len(items); n++ {
+		want := plainChanges(items[:n])
+		gen, err := NewPermutationGenerator(items[:n], Gray())
+
+		if err != nil {
+			t.Fatalf("Error'd with: %v", err)
+		}
+
+		testGenerator[string](t, gen, want)
+
+		gen.Range(0, len(want))
+
+		for gen.Next() {
+			if c := gen.LastChange(); gen.Position() > 0 && (c.Kind != ChangeSwap || c.J != c.I+1) {
+				t.Fatalf("Not an adjacent swap at %v: %v", gen.Position(), c)
+			}
+		}
+
+		if len(want) > 1 {
+			gen.Reset()
+			testChanges[string](t, gen, items[:n])
+		}
+
+		testPrev(t, items[:n], func(opts ...GeneratorOption) reversibleGenerator[string] {
+			gen, _ := NewPermutationGenerator(items[:n], append(opts, Gray())...)
+			return gen
+		})
+	}
+
+	gen, _ := NewPermutationIndexGenerator(4, Gray())
+	testClone[int](t, gen)
+
+	if _, err := NewPermutationGenerator(items, Gray(), Lexicographic()); err == nil {
+		t.Errorf("Didn't err on gray code and lexicographic order")
+	}
+}
+
+func TestPermutationGeneratorAll(t *testing.T) {
+	gen, _ := NewPermutationGenerator(_perm_items)
+	want := make([][]int, 0, PermutationCount(4))
+
+	for gen.Next() {
+		want = append(want, gen.CurrentCopy())
+	}
+
+	res := make([][]int, 0, len(want))
+
+	for i, p := range gen.All() {
+		if i != len(res) {
+			t.Errorf("Wrong rank, got: %v, want: %v", i, len(res))
+		}
+		res = append(res, slices.Clone(p))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, want)
+	}
+
+	res = res[:0]
+
+	for p := range gen.Values() {
+		res = append(res, slices.Clone(p))
+	}
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal (values), \ngot: %v, \nwant: %v", res, want)
+	}
+
+	// break + resume
+	for i := range gen.All() {
+		if i == 10 {
+			break
+		}
+	}
+
+	if gen.Next(); slices.Compare(gen.Current(), want[11]) != 0 {
+		t.Errorf("Not resumed after break, got: %v, want: %v", gen.Current(), want[11])
+	}
+
+	gen.Reset()
+
+	if gen.Next(); slices.Compare(gen.Current(), want[0]) != 0 {
+		t.Errorf("Not reset after break, got: %v, want: %v", gen.Current(), want[0])
+	}
+}
+
+func TestPermutationsSeq(t *testing.T) {
+	for i, want := range _perm_table {
+		i := i
+		want := want
+		input := _perm_items[0:i]
+
+		t.Run(fmt.Sprintf("p(%d)=%d", i, PermutationCount(i)), func(t *testing.T) {
+			res := make([][]int, 0, len(want))
+
+			for r, p := range PermutationsSeq(input) {
+				if r != len(res) {
+					t.Errorf("Wrong rank, got: %v, want: %v", r, len(res))
+				}
+				res = append(res, slices.Clone(p))
+			}
+
+			slices.SortFunc(res, func(e1, e2 []int) int {
+				return slices.Compare(e1, e2)
+			})
+
+			if compareSliceOfSlices(res, want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, want)
+			}
+		})
+	}
+}
+
+func TestPermutationGeneratorSeek(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7}
+
+	for n := 1; n <= len(items); n++ {
+		gen, _ := NewPermutationGenerator(items[:n])
+		want := make([][]int, 0, PermutationCount(n))
+
+		for gen.Next() {
+			if gen.Position() != len(want) {
+				t.Errorf("p(%d): wrong position, got: %v, want: %v", n, gen.Position(), len(want))
+			}
+			want = append(want, gen.CurrentCopy())
+		}
+
+		for r := range want {
+			if err := gen.Seek(r); err != nil {
+				t.Fatalf("p(%d): error'd with: %v", n, err)
+			}
+
+			if gen.Position() != r-1 {
+				t.Errorf("p(%d): wrong position after seek, got: %v, want: %v", n, gen.Position(), r-1)
+			}
+
+			// check a few steps, as the state is fully restored
+			for i := r; i < len(want) && i < r+n; i++ {
+				if !gen.Next() || slices.Compare(gen.Current(), want[i]) != 0 {
+					t.Fatalf("p(%d): not equal at %v after seek to %v, \ngot: %v, \nwant: %v", n, i, r, gen.Current(), want[i])
+				}
+			}
+		}
+
+		gen.Seek(len(want) - 1)
+		gen.Next()
+
+		if gen.Next() {
+			t.Errorf("p(%d): didn't return false on end after seek", n)
+		}
+		if err := gen.Seek(len(want)); err != nil || gen.Next() {
+			t.Errorf("p(%d): didn't seek to end", n)
+		}
+		if gen.Seek(len(want)+1) == nil || gen.Seek(-1) == nil {
+			t.Errorf("p(%d): didn't err on rank out of range", n)
+		}
+	}
+}
+
+func TestPermutationGeneratorShard(t *testing.T) {
+	for n := 1; n <= len(_perm_items); n++ {
+		gen, _ := NewPermutationGenerator(_perm_items[:n])
+		testShards[int](t, gen)
+	}
+}
+
 func BenchmarkPermutations(b *testing.B) {
 	items := []int{1, 2, 3, 4, 5, 6}
 
@@ -0,0 +1,219 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"testing"
+)
+
+// Checks walking the sequence of generators created by factory backwards, from the end
+// with the Reverse option, from every position and right after Seek, against the forward
+// sequence.
+func testPrev[T cmp.Ordered](t *testing.T, elems []T, factory func(opts ...GeneratorOption) reversibleGenerator[T]) {
+	t.Helper()
+
+	want := make([][]T, 0)
+
+	for gen := factory(); gen.Next(); {
+		want = append(want, gen.CurrentCopy())
+	}
+
+	gen := factory(Reverse())
+
+	if gen.Position() != len(want) || gen.Next() {
+		t.Fatalf("Not at the end with Reverse, position: %v", gen.Position())
+	}
+
+	var prev []T
+
+	for r := len(want) - 1; r >= 0; r-- {
+		if !gen.Prev() || gen.Position() != r {
+			t.Fatalf("Didn't move to %v, position: %v", r, gen.Position())
+		}
+
+		c := gen.LastChange()
+
+		if prev == nil && c.Kind != ChangeAll {
+			t.Errorf("Want change of all elements first, got %v", c)
+		}
+
+		prev = applyChange(t, prev, gen.Current(), c, elems)
+
+		if slices.Compare(gen.Current(), want[r]) != 0 || slices.Compare(prev, want[r]) != 0 {
+			t.Fatalf("Not equal at %v after %v, \ngot: %v (%v), \nwant: %v", r, c, gen.Current(), prev, want[r])
+		}
+	}
+
+	if gen.Prev() || gen.Prev() {
+		t.Errorf("Didn't return false on the beginning")
+	}
+
+	if len(want) > 1 && (!gen.Next() || slices.Compare(gen.Current(), want[1]) != 0) {
+		t.Errorf("Not resumed after the beginning, got: %v, want: %v", gen.Current(), want[1])
+	}
+
+	// iterators walk the sequence backwards as well
+	it := factory(Reverse()).(interface {
+		All() iter.Seq2[int, []T]
+		Values() iter.Seq[[]T]
+	})
+	r := len(want)
+
+	for i, v := range it.All() {
+		if r--; i != r || slices.Compare(v, want[r]) != 0 {
+			t.Fatalf("Not equal at %v in All with Reverse, \ngot: %v (%v), \nwant: %v", r, v, i, want[r])
+		}
+	}
+
+	if res := slices.Collect(it.Values()); r != 0 || len(res) != len(want) {
+		t.Errorf("Wrong number of results with Reverse, got: %v and %v, want: %v", len(want)-r, len(res), len(want))
+	}
+
+	// back and forth from every position
+	gen = factory()
+
+	for r := 0; gen.Next(); r++ {
+		if r > 0 {
+			if !gen.Prev() || slices.Compare(gen.Current(), want[r-1]) != 0 {
+				t.Fatalf("Not equal at %v after Prev, \ngot: %v, \nwant: %v", r-1, gen.Current(), want[r-1])
+			}
+			if !gen.Next() || slices.Compare(gen.Current(), want[r]) != 0 {
+				t.Fatalf("Not equal at %v after Prev and Next, \ngot: %v, \nwant: %v", r, gen.Current(), want[r])
+			}
+		} else if gen.Prev() {
+			t.Errorf("Didn't return false on the first result")
+		}
+	}
+
+	// right after Seek, from every rank up to the end
+	gen = factory()
+
+	for r := 0; r <= len(want); r++ {
+		gen.Seek(r)
+
+		if r == 0 {
+			if gen.Prev() {
+				t.Errorf("Didn't return false after Seek(0)")
+			}
+			continue
+		}
+
+		if !gen.Prev() || gen.Position() != r-1 || slices.Compare(gen.Current(), want[r-1]) != 0 {
+			t.Fatalf("Not equal at %v after Seek(%v), \ngot: %v, \nwant: %v", r-1, r, gen.Current(), want[r-1])
+		}
+
+		if c := gen.LastChange(); c.Kind != ChangeAll {
+			t.Errorf("Want change of all elements after Seek, got %v", c)
+		}
+
+		if r < len(want) && (!gen.Next() || slices.Compare(gen.Current(), want[r]) != 0) {
+			t.Fatalf("Not equal at %v after Seek(%v), Prev and Next, \ngot: %v, \nwant: %v", r, r, gen.Current(), want[r])
+		}
+	}
+
+	if len(want) < 3 {
+		return
+	}
+
+	// within a range
+	gen = factory(Reverse())
+	gen.Range(1, len(want)-1)
+	gen.Prev()
+	gen.Reset()
+
+	for r := len(want) - 2; r >= 1; r-- {
+		if !gen.Prev() || slices.Compare(gen.Current(), want[r]) != 0 {
+			t.Fatalf("Not equal at %v in range, \ngot: %v, \nwant: %v", r, gen.Current(), want[r])
+		}
+	}
+
+	if gen.Prev() {
+		t.Errorf("Didn't return false on the beginning of the range")
+	}
+}
+
+func TestPrev(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+
+	t.Run("combinations", func(t *testing.T) {
+		testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewCombinationGenerator(2, items, opts...)
+			return gen
+		})
+	})
+	t.Run("permutations", func(t *testing.T) {
+		testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewPermutationGenerator(items, opts...)
+			return gen
+		})
+	})
+	t.Run("multipermutations", func(t *testing.T) {
+		testPrev(t, items[:3], func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewMultiPermutationGenerator(items[:3], []int{2, 1, 2}, opts...)
+			return gen
+		})
+	})
+	t.Run("variations", func(t *testing.T) {
+		testPrev(t, items[:3], func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewVariationGenerator(3, items[:3], opts...)
+			return gen
+		})
+	})
+	t.Run("variation indices", func(t *testing.T) {
+		testPrev(t, indices(2), func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewVariationIndexGenerator(3, 2, opts...)
+			return gen
+		})
+	})
+	t.Run("combinations with repetition", func(t *testing.T) {
+		testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewCombinationWithRepetitionGenerator(3, items, opts...)
+			return gen
+		})
+	})
+	t.Run("k-permutations", func(t *testing.T) {
+		testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewKPermutationGenerator(2, items, opts...)
+			return gen
+		})
+	})
+	t.Run("k-permutation indices", func(t *testing.T) {
+		testPrev(t, indices(5), func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewKPermutationIndexGenerator(3, 5, opts...)
+			return gen
+		})
+	})
+	t.Run("multicombinations", func(t *testing.T) {
+		testPrev(t, items[:3], func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewMultiCombinationGenerator(3, items[:3], []int{2, 1, 2}, opts...)
+			return gen
+		})
+	})
+	t.Run("multicombination indices", func(t *testing.T) {
+		testPrev(t, indices(4), func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewMultiCombinationIndexGenerator(5, []int{3, 1, 2, 4}, opts...)
+			return gen
+		})
+	})
+	t.Run("subsets", func(t *testing.T) {
+		testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewSubsetGenerator(1, 3, items, opts...)
+			return gen
+		})
+	})
+	t.Run("subsets gray", func(t *testing.T) {
+		testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[int] {
+			gen, _ := NewSubsetGenerator(0, len(items), items, append(opts, Gray())...)
+			return gen
+		})
+	})
+
+	if _, err := NewCombinationGenerator(2, items, Gray()); err == nil {
+		t.Errorf("Didn't err on an unsupported option")
+	}
+}
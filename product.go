@@ -0,0 +1,602 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"math/big"
+	"slices"
+)
+
+// ProductCount returns the number of elements of the Cartesian product of sets
+// with the given sizes.
+func ProductCount(sizes []int) int {
+	count := 1
+
+	for _, n := range sizes {
+		count *= n
+	}
+
+	return count
+}
+
+// ProductCountChecked returns the number of elements of the Cartesian product of sets
+// with the given sizes, or [ErrOverflow] if it doesn't fit in an int.
+func ProductCountChecked(sizes []int) (int, error) {
+	count := 1
+
+	for _, n := range sizes {
+		var ok bool
+
+		if count, ok = mul(count, n); !ok {
+			return 0, ErrOverflow
+		}
+	}
+
+	return count, nil
+}
+
+// ProductCountBig returns the number of elements of the Cartesian product of sets
+// with the given sizes as [big.Int].
+func ProductCountBig(sizes []int) *big.Int {
+	count := big.NewInt(1)
+
+	for _, n := range sizes {
+		count.Mul(count, big.NewInt(int64(n)))
+	}
+
+	return count
+}
+
+// ProductRank returns the rank of an element of the Cartesian product of sets with the
+// given sizes in the lexicographic order. The element is given as positions in the sets,
+// and its rank is the number the positions represent in the mixed radix given by sizes.
+//
+// Returns an error if sizes is empty or contains a value less than 1, or if positions
+// don't match sizes.
+func ProductRank(sizes, positions []int) (int, error) {
+	if err := checkSizes(sizes); err != nil {
+		return 0, err
+	}
+
+	if len(positions) != len(sizes) {
+		return 0, fmt.Errorf("positions don't match sizes")
+	}
+
+	rank := 0
+
+	for i, p := range positions {
+		if p < 0 || p >= sizes[i] {
+			return 0, fmt.Errorf("position out of range")
+		}
+
+		rank = rank*sizes[i] + p
+	}
+
+	return rank, nil
+}
+
+// ProductUnrank returns positions of the element of the Cartesian product of sets with the
+// given sizes at the given rank in the lexicographic order. It's the inverse of [ProductRank].
+//
+// Returns an error if sizes is empty or contains a value less than 1, or if the rank is not
+// in the range [0, ProductCount(sizes)).
+func ProductUnrank(sizes []int, rank int) ([]int, error) {
+	if err := checkSizes(sizes); err != nil {
+		return nil, err
+	}
+
+	if rank < 0 || rank >= ProductCount(sizes) {
+		return nil, fmt.Errorf("rank out of range")
+	}
+
+	positions := make([]int, len(sizes))
+
+	for i := len(sizes) - 1; i >= 0; i-- {
+		positions[i] = rank % sizes[i]
+		rank /= sizes[i]
+	}
+
+	return positions, nil
+}
+
+// Checks that sizes are valid sizes of sets for the Cartesian product.
+func checkSizes(sizes []int) error {
+	if len(sizes) == 0 {
+		return fmt.Errorf("input slice is nil or empty")
+	}
+
+	for _, n := range sizes {
+		if n <= 0 {
+			return fmt.Errorf("sizes must be >= 1")
+		}
+	}
+
+	return nil
+}
+
+// Product returns the Cartesian product of the alphabets, i.e. all slices that have
+// an element of alphabets[i] at the position i, in the lexicographic order.
+// Returns an error when alphabets or any of them is nil or empty.
+func Product[T any](alphabets [][]T) ([][]T, error) {
+	return ProductWithLimit(alphabets)
+}
+
+// ProductWithLimit is like [Product], but returns [TooManyResultsError]
+// before allocating the results if there are more of them than allowed by opts.
+func ProductWithLimit[T any](alphabets [][]T, opts ...LimitOption) ([][]T, error) {
+	t, err := ProductTable(alphabets, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Rows(), nil
+}
+
+// ProductTable is like [ProductWithLimit], but stores the results in a [Table].
+func ProductTable[T any](alphabets [][]T, opts ...LimitOption) (*Table[T], error) {
+	gen, err := NewProductGenerator(alphabets)
+
+	if err != nil {
+		return nil, err
+	}
+
+	k := len(alphabets)
+	count, err := ProductCountChecked(gen.sizes)
+
+	if err := checkLimit[T](count, err, k, opts, func() *big.Int { return ProductCountBig(gen.sizes) }); err != nil {
+		return nil, err
+	}
+
+	return fillTable(gen, count, k), nil
+}
+
+// ProductGenerator implements a [Generator] interface for generating the Cartesian product
+// of alphabets, where every position has its own alphabet. It's a generalization of
+// [VariationGenerator], which uses the same alphabet for every position.
+type ProductGenerator[T any] struct {
+	k, row, from, to int
+	alphabets        [][]T
+	dest             []T
+	idx, sizes, pows []int
+	opts             []GeneratorOption
+	reverse          bool
+	change           Change
+}
+
+// Init initializes a generator for the Cartesian product of alphabets, whose results
+// have an element of alphabets[i] at the position i. Returns an error if alphabets
+// or any of them is nil or empty.
+//
+// Supported options are [Lexicographic] and [Reverse].
+func (gen *ProductGenerator[T]) Init(alphabets [][]T, opts ...GeneratorOption) error {
+	if len(alphabets) == 0 {
+		return fmt.Errorf("input slice is nil or empty")
+	}
+
+	sizes := make([]int, len(alphabets))
+
+	for i, a := range alphabets {
+		if len(a) == 0 {
+			return fmt.Errorf("alphabet is nil or empty")
+		}
+
+		sizes[i] = len(a)
+	}
+
+	return gen.init(sizes, alphabets, opts)
+}
+
+// Initializes the generator for the Cartesian product of sets with the given sizes.
+// If alphabets is nil, the generator is in index mode and produces positions instead of
+// elements, which requires T to be int.
+func (gen *ProductGenerator[T]) init(sizes []int, alphabets [][]T, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if o.gray {
+		return fmt.Errorf("gray code order is not supported")
+	}
+
+	k := len(sizes)
+
+	if k != gen.k {
+		gen.dest = make([]T, k)
+		gen.pows = make([]int, k)
+	}
+
+	gen.k = k
+	gen.sizes = sizes
+
+	for i := range k {
+		gen.pows[i] = saturate(ProductCountChecked(sizes[i+1:]))
+	}
+
+	gen.row = 0
+	gen.change = Change{}
+	gen.alphabets = alphabets
+	gen.idx = nil
+
+	if alphabets == nil {
+		gen.idx = any(gen.dest).([]int)
+	}
+
+	gen.opts = opts
+	gen.reverse = o.reverse
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.reverse {
+		gen.end()
+	}
+
+	return nil
+}
+
+// Returns the number of results in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *ProductGenerator[T]) count() int {
+	return saturate(ProductCountChecked(gen.sizes))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
+func (gen *ProductGenerator[T]) Reset() {
+	s, from, to := gen.dest, gen.from, gen.to
+	gen.init(gen.sizes, gen.alphabets, gen.opts)
+	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
+}
+
+// Current returns the internal slice that holds the current result.
+// If you need to modify the returned slice, use [ProductGenerator.CurrentCopy] instead.
+func (gen *ProductGenerator[T]) Current() []T {
+	return gen.dest
+}
+
+// CurrentCopy returns a copy of the internal slice that holds the current result.
+// If you don't need to modify the returned slice, use [ProductGenerator.Current] to avoid allocation.
+func (gen *ProductGenerator[T]) CurrentCopy() []T {
+	return slices.Clone(gen.dest)
+}
+
+// SetDest sets a destination slice that will receive the results.
+// Returns an error if there's not enough capacity in the slice.
+//
+// After the destination slice is set, subsequent calls to [ProductGenerator.Current]
+// will return the provided slice.
+func (gen *ProductGenerator[T]) SetDest(dest []T) error {
+	if got := cap(dest); got < gen.k {
+		return fmt.Errorf(capacityMsg(gen.k, got))
+	}
+
+	copy(dest, gen.dest)
+	gen.dest = dest
+
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
+	return nil
+}
+
+// Next produces a new result in the generator. If it returns false,
+// there are no more results available.
+func (gen *ProductGenerator[T]) Next() bool {
+	if gen.k <= 0 || gen.row >= gen.to {
+		return false
+	}
+
+	gen.fill(gen.row)
+
+	if gen.change.Kind == ChangeNone {
+		gen.change = Change{Kind: ChangeAll}
+	} else {
+		n := gen.sizes[gen.k-1]
+		last := gen.row % n
+		gen.change = rangeChange(gen.changed(gen.row), gen.k, (last+n-1)%n, last)
+	}
+
+	gen.row++
+
+	return true
+}
+
+// Prev produces the result before the current one in the sequence of the generator.
+// If it returns false, there are no previous results available in the range.
+func (gen *ProductGenerator[T]) Prev() bool {
+	// right after Seek, the previous result is the one before the sought rank
+	rank := gen.row - 2
+
+	if gen.change.Kind == ChangeNone {
+		rank = min(gen.row, gen.to) - 1
+	}
+
+	if gen.k <= 0 || rank < gen.from {
+		return false
+	}
+
+	gen.row = rank + 1
+	gen.fill(rank)
+
+	if gen.change.Kind == ChangeNone {
+		gen.change = Change{Kind: ChangeAll}
+	} else {
+		n := gen.sizes[gen.k-1]
+		last := gen.row % n
+		gen.change = rangeChange(gen.changed(gen.row), gen.k, last, (last+n-1)%n)
+	}
+
+	return true
+}
+
+// Writes the result at the rank into dest.
+func (gen *ProductGenerator[T]) fill(rank int) {
+	if gen.idx != nil {
+		for col := 0; col < gen.k; col++ {
+			gen.idx[col] = (rank / gen.pows[col]) % gen.sizes[col]
+		}
+	} else {
+		for col := 0; col < gen.k; col++ {
+			i := (rank / gen.pows[col]) % gen.sizes[col]
+			gen.dest[col] = gen.alphabets[col][i]
+		}
+	}
+}
+
+// Returns the first column that differs between results at the rank and the one before it.
+func (gen *ProductGenerator[T]) changed(rank int) int {
+	// digits after the first changed one in the mixed radix wrap to 0
+	col := gen.k - 1
+
+	for r := rank; r%gen.sizes[col] == 0; col-- {
+		r /= gen.sizes[col]
+	}
+
+	return col
+}
+
+// LastChange returns the change of the current result made by the last call to
+// [ProductGenerator.Next]. Every result after the first one differs from the previous
+// one in the last element ([ChangeReplace]), or in elements from some position to the
+// end ([ChangeRange]). Old and New of [ChangeReplace] are positions in the last alphabet.
+func (gen *ProductGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current result in the sequence of the generator,
+// or -1 if [ProductGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [ProductGenerator.Prev] is called.
+func (gen *ProductGenerator[T]) Position() int {
+	return gen.row - 1
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [ProductGenerator.Next] produces the result
+// at that rank, and [ProductGenerator.Prev]
+// the one before it. Seeking to the end of the range set by [ProductGenerator.Range]
+// (ProductCount(sizes) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *ProductGenerator[T]) Seek(rank int) error {
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.row = rank
+
+	return nil
+}
+
+// Range limits the generator to the results with ranks in the range [from, to)
+// and moves it to the rank from. [ProductGenerator.Reset] preserves the range, and
+// [ProductGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, ProductCount(sizes)].
+func (gen *ProductGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *ProductGenerator[T]) end() {
+	gen.row = gen.to + 1
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *ProductGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// All returns an iterator over all results paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [ProductGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded result and can be resumed with [ProductGenerator.Next] or restarted with
+// [ProductGenerator.Reset].
+func (gen *ProductGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [ProductGenerator.All], but it yields only the results.
+func (gen *ProductGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following results, as if by calling
+// [ProductGenerator.Next] and [ProductGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of results written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *ProductGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [ProductGenerator.NextBatch], but writes the results one after another
+// into dst, where every result takes len(alphabets) elements. Writes as many results as fit into dst.
+func (gen *ProductGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.k)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler]. It encodes the state of the algorithm,
+// the position of the generator, its range and options, and the sizes of alphabets, but not
+// the alphabets, so the state can be restored only into a generator initialized with the
+// same input and options.
+func (gen *ProductGenerator[T]) MarshalBinary() ([]byte, error) {
+	return gen.state().MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]. It restores the state encoded by
+// [ProductGenerator.MarshalBinary] into the generator, which must be initialized with the
+// same input and options, so that [ProductGenerator.Current] returns the same result, and the following
+// call to [ProductGenerator.Next] continues the sequence. Returns an error if the state is
+// invalid or if it doesn't match the generator.
+func (gen *ProductGenerator[T]) UnmarshalBinary(data []byte) error {
+	st := new(generatorState)
+
+	if err := st.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// MarshalJSON implements [json.Marshaler]. It's the JSON equivalent of [ProductGenerator.MarshalBinary].
+func (gen *ProductGenerator[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gen.state())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It's the JSON equivalent of [ProductGenerator.UnmarshalBinary].
+func (gen *ProductGenerator[T]) UnmarshalJSON(data []byte) error {
+	st := new(generatorState)
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// Returns the checkpoint of the generator. Its variable is the row.
+func (gen *ProductGenerator[T]) state() *generatorState {
+	st := newState(gen.opts, gen.Position(), gen.from, gen.to, gen.change, gen.sizes...)
+	st.Vars = []int{gen.row}
+
+	return st
+}
+
+// Restores the checkpoint made by state, and writes the current result into dest.
+func (gen *ProductGenerator[T]) load(st *generatorState) error {
+	if err := st.check(gen.opts, gen.count(), gen.sizes, 1); err != nil {
+		return err
+	}
+
+	if st.Vars[0] != st.Pos+1 {
+		return fmt.Errorf("invalid state data")
+	}
+
+	gen.row = st.Vars[0]
+	gen.from, gen.to = st.From, st.To
+	gen.change = st.Change
+
+	if gen.change.Kind != ChangeNone && gen.row > 0 {
+		gen.fill(gen.row - 1)
+	}
+
+	return nil
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current result, and shares only the input with the original.
+func (gen *ProductGenerator[T]) Clone() *ProductGenerator[T] {
+	c := *gen
+	c.dest = slices.Clone(gen.dest)
+	c.pows = slices.Clone(gen.pows)
+
+	if gen.idx != nil {
+		c.idx = any(c.dest).([]int)
+	}
+
+	return &c
+}
+
+// NewProductGenerator creates and initializes a new ProductGenerator.
+// Arguments and returned errors are the same ones from the [ProductGenerator.Init] method.
+func NewProductGenerator[T any](alphabets [][]T, opts ...GeneratorOption) (*ProductGenerator[T], error) {
+	gen := new(ProductGenerator[T])
+	err := gen.Init(alphabets, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// NewProductIndexGenerator creates a new ProductGenerator that produces the Cartesian product
+// of positions in implicit alphabets with the given sizes, i.e. of integers in the ranges
+// [0, sizes[i]), without requiring the alphabets. Returns an error if sizes is empty or
+// contains a value less than 1, or if the options can't be applied, see [ProductGenerator.Init].
+func NewProductIndexGenerator(sizes []int, opts ...GeneratorOption) (*ProductGenerator[int], error) {
+	if err := checkSizes(sizes); err != nil {
+		return nil, err
+	}
+
+	gen := new(ProductGenerator[int])
+
+	if err := gen.init(slices.Clone(sizes), nil, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// ProductSeq returns an iterator over the Cartesian product of alphabets, paired with
+// the rank of every result. The sequence is empty if the arguments are invalid, see
+// [ProductGenerator.Init] for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func ProductSeq[T any](alphabets [][]T) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewProductGenerator(alphabets)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+var (
+	_prod_items = []string{"A", "B", "C", "D", "E"}
+
+	// alphabets are prefixes of _prod_items, so that changes can be checked against it
+	_prod_data = []struct {
+		alphabets [][]string
+		want      [][]string
+	}{
+		{
+			alphabets: [][]string{_prod_items[:3]},
+			want:      [][]string{{"A"}, {"B"}, {"C"}},
+		},
+		{
+			alphabets: [][]string{_prod_items[:2], _prod_items[:3]},
+			want: [][]string{
+				{"A", "A"},
+				{"A", "B"},
+				{"A", "C"},
+				{"B", "A"},
+				{"B", "B"},
+				{"B", "C"},
+			},
+		},
+		{
+			alphabets: [][]string{_prod_items[:3], _prod_items[:1], _prod_items[:2]},
+			want: [][]string{
+				{"A", "A", "A"},
+				{"A", "A", "B"},
+				{"B", "A", "A"},
+				{"B", "A", "B"},
+				{"C", "A", "A"},
+				{"C", "A", "B"},
+			},
+		},
+		{
+			alphabets: [][]string{_prod_items[:2], _prod_items[:2], _prod_items[:1]},
+			want: [][]string{
+				{"A", "A", "A"},
+				{"A", "B", "A"},
+				{"B", "A", "A"},
+				{"B", "B", "A"},
+			},
+		},
+	}
+)
+
+// Returns the sizes of alphabets.
+func productSizes[T any](alphabets [][]T) []int {
+	sizes := make([]int, len(alphabets))
+
+	for i, a := range alphabets {
+		sizes[i] = len(a)
+	}
+
+	return sizes
+}
+
+func TestProduct(t *testing.T) {
+	for i, d := range _prod_data {
+		res, err := Product(d.alphabets)
+
+		if err != nil {
+			t.Errorf("Error'd with: %v", err)
+		}
+
+		if compareSliceOfSlices(res, d.want) != 0 {
+			t.Errorf("Not equal (%v), \ngot: %v, \nwant: %v", i, res, d.want)
+		}
+	}
+
+	items := []int{1, 2, 3}
+	res, _ := Product([][]int{items, items, items})
+	want, _ := Variations(3, items)
+
+	if compareSliceOfSlices(res, want) != 0 {
+		t.Errorf("Not equal to variations, \ngot: %v, \nwant: %v", res, want)
+	}
+
+	for _, alphabets := range [][][]int{nil, {}, {items, nil}, {{}, items}} {
+		if _, err := Product(alphabets); err == nil {
+			t.Errorf("Didn't err on %v", alphabets)
+		}
+	}
+}
+
+func TestProductCount(t *testing.T) {
+	if n := ProductCount([]int{3, 5, 2}); n != 30 {
+		t.Errorf("Want 30, got %v", n)
+	}
+	if _, err := ProductCountChecked(slices.Repeat([]int{2}, 64)); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Want ErrOverflow, got %v", err)
+	}
+	if n := ProductCountBig(slices.Repeat([]int{2}, 64)).String(); n != "18446744073709551616" {
+		t.Errorf("Want 18446744073709551616, got %v", n)
+	}
+}
+
+func TestProductRank(t *testing.T) {
+	for _, d := range _prod_data {
+		sizes := productSizes(d.alphabets)
+
+		t.Run(fmt.Sprintf("p%v", sizes), func(t *testing.T) {
+			for r, w := range d.want {
+				positions, err := ProductUnrank(sizes, r)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				for i, p := range positions {
+					if d.alphabets[i][p] != w[i] {
+						t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", r, positions, w)
+						break
+					}
+				}
+
+				if rank, err := ProductRank(sizes, positions); err != nil || rank != r {
+					t.Errorf("Wrong rank for %v, got: %v (%v), want: %v", positions, rank, err, r)
+				}
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := ProductUnrank([]int{2, 3}, 6); err == nil {
+			t.Errorf("Didn't err on rank out of range")
+		}
+		if _, err := ProductUnrank([]int{2, 0}, 0); err == nil {
+			t.Errorf("Didn't err on size = 0")
+		}
+		if _, err := ProductRank([]int{2, 3}, []int{1, 3}); err == nil {
+			t.Errorf("Didn't err on position out of range")
+		}
+		if _, err := ProductRank([]int{2, 3}, []int{1}); err == nil {
+			t.Errorf("Didn't err on positions not matching sizes")
+		}
+		if _, err := ProductRank(nil, nil); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+	})
+}
+
+func TestProductGenerator(t *testing.T) {
+	for _, d := range _prod_data {
+		d := d
+
+		t.Run(fmt.Sprintf("p%v", productSizes(d.alphabets)), func(t *testing.T) {
+			gen, err := NewProductGenerator(d.alphabets)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			testGenerator[string](t, gen, d.want)
+
+			if len(d.want) > 1 {
+				gen.Reset()
+				testChanges[string](t, gen, _prod_items)
+			}
+
+			testPrev(t, _prod_items, func(opts ...GeneratorOption) reversibleGenerator[string] {
+				gen, _ := NewProductGenerator(d.alphabets, opts...)
+				return gen
+			})
+
+			gen, _ = NewProductGenerator(d.alphabets)
+			testClone[string](t, gen)
+
+			gen, _ = NewProductGenerator(d.alphabets)
+			testShards[string](t, gen)
+
+			testState(t, func() stateGenerator[string] {
+				gen, _ := NewProductGenerator(d.alphabets)
+				return gen
+			})
+
+			res := make([][]string, 0, len(d.want))
+
+			for i, v := range ProductSeq(d.alphabets) {
+				if i != len(res) {
+					t.Errorf("Wrong rank, got: %v, want: %v", i, len(res))
+				}
+				res = append(res, slices.Clone(v))
+			}
+
+			if compareSliceOfSlices(res, d.want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, d.want)
+			}
+		})
+	}
+
+	if _, err := NewProductGenerator([][]int{{1, 2}}, Gray()); err == nil {
+		t.Errorf("Didn't err on an unsupported option")
+	}
+
+	for range ProductSeq([][]int{{1}, nil}) {
+		t.Errorf("Didn't return an empty sequence on invalid input")
+	}
+}
+
+func TestProductIndexGenerator(t *testing.T) {
+	for _, sizes := range [][]int{{1}, {3}, {2, 3}, {3, 1, 2}, {2, 2, 2}} {
+		t.Run(fmt.Sprintf("p%v", sizes), func(t *testing.T) {
+			gen, err := NewProductIndexGenerator(sizes)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			alphabets := make([][]int, len(sizes))
+
+			for i, n := range sizes {
+				alphabets[i] = indices(n)
+			}
+
+			want, _ := Product(alphabets)
+			testGenerator[int](t, gen, want)
+		})
+	}
+
+	for _, sizes := range [][]int{nil, {2, 0}} {
+		if _, err := NewProductIndexGenerator(sizes); err == nil {
+			t.Errorf("Didn't err on sizes %v", sizes)
+		}
+	}
+}
+
+func BenchmarkProductGenerator(b *testing.B) {
+	alphabets := [][]int{{1, 2, 3}, {1, 2, 3, 4, 5}, {1, 2}}
+
+	b.Run(fmt.Sprintf("p(3,5,2)=%d", ProductCount([]int{3, 5, 2})), func(b *testing.B) {
+		gen := new(ProductGenerator[int])
+
+		for i := 0; i < b.N; i++ {
+			gen.Init(alphabets)
+			for gen.Next() {
+				gen.Current()
+			}
+		}
+	})
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// Version of the binary encoding of generatorState.
+const stateVersion = 2
+
+// Flags of options in the binary encoding of generatorState.
+const (
+	stateGray = 1 << iota
+	stateLex
+	stateReverse
+)
+
+// generatorState is a checkpoint of a generator: sizes of its input, its options, its
+// position in the sequence, its range, the last change, and the internal state of its
+// algorithm, i.e. the variables and slices that the algorithm carries from one result
+// to the next. Elements of the input are not part of it, so the state can be restored
+// only into a generator initialized with the same input and options.
+type generatorState struct {
+	Shape   []int   `json:"shape"`
+	Gray    bool    `json:"gray"`
+	Lex     bool    `json:"lex"`
+	Reverse bool    `json:"reverse"`
+	Pos     int     `json:"pos"`
+	From    int     `json:"from"`
+	To      int     `json:"to"`
+	Change  Change  `json:"change"`
+	Vars    []int   `json:"vars"`
+	Slices  [][]int `json:"slices"`
+}
+
+// Creates the checkpoint of a generator with the options opts, without the state of its algorithm.
+func newState(opts []GeneratorOption, pos, from, to int, change Change, shape ...int) *generatorState {
+	o := applyOptions(opts)
+
+	return &generatorState{
+		Shape:   slices.Clone(shape),
+		Gray:    o.gray,
+		Lex:     o.lex,
+		Reverse: o.reverse,
+		Pos:     pos,
+		From:    from,
+		To:      to,
+		Change:  change,
+	}
+}
+
+// MarshalBinary encodes the state as a version byte followed by varints: flags of the
+// options, the number of sizes and the sizes, the position, the range, the kind and the
+// fields of the change, the number of variables and the variables, and the number of
+// slices followed by the length and the elements of every slice.
+func (st *generatorState) MarshalBinary() ([]byte, error) {
+	flags := 0
+
+	for i, f := range []bool{st.Gray, st.Lex, st.Reverse} {
+		if f {
+			flags |= 1 << i
+		}
+	}
+
+	b := []byte{stateVersion}
+	b = binary.AppendVarint(b, int64(flags))
+	b = appendVarints(b, st.Shape)
+
+	c := st.Change
+
+	for _, v := range []int{st.Pos, st.From, st.To, int(c.Kind), c.I, c.J, c.Old, c.New} {
+		b = binary.AppendVarint(b, int64(v))
+	}
+
+	b = appendVarints(b, st.Vars)
+	b = binary.AppendVarint(b, int64(len(st.Slices)))
+
+	for _, s := range st.Slices {
+		b = appendVarints(b, s)
+	}
+
+	return b, nil
+}
+
+// Appends the length of s and its elements to b as varints.
+func appendVarints(b []byte, s []int) []byte {
+	b = binary.AppendVarint(b, int64(len(s)))
+
+	for _, v := range s {
+		b = binary.AppendVarint(b, int64(v))
+	}
+
+	return b
+}
+
+// UnmarshalBinary decodes the state encoded by [generatorState.MarshalBinary].
+func (st *generatorState) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != stateVersion {
+		return fmt.Errorf("invalid state data")
+	}
+
+	data = data[1:]
+	ok := true
+
+	next := func() int {
+		v, n := binary.Varint(data)
+
+		if n <= 0 {
+			ok = false
+			return 0
+		}
+
+		data = data[n:]
+		return int(v)
+	}
+
+	// every varint takes at least one byte, which limits the length
+	length := func() int {
+		l := next()
+
+		if !ok || l < 0 || l > len(data) {
+			ok = false
+			return 0
+		}
+
+		return l
+	}
+
+	list := func() []int {
+		s := make([]int, length())
+
+		for i := range s {
+			s[i] = next()
+		}
+
+		return s
+	}
+
+	flags := next()
+	st.Gray, st.Lex, st.Reverse = flags&stateGray != 0, flags&stateLex != 0, flags&stateReverse != 0
+	st.Shape = list()
+	st.Pos, st.From, st.To = next(), next(), next()
+	st.Change = Change{Kind: ChangeKind(next()), I: next(), J: next(), Old: next(), New: next()}
+	st.Vars = list()
+	st.Slices = make([][]int, length())
+
+	for i := range st.Slices {
+		st.Slices[i] = list()
+	}
+
+	if !ok || len(data) != 0 {
+		return fmt.Errorf("invalid state data")
+	}
+
+	return nil
+}
+
+// Checks if the state can be restored into a generator with the options opts, a sequence
+// of count items and the input of the given shape, and if it has nvars variables and as
+// many slices as there are lengths in lens, each of the given length.
+func (st *generatorState) check(opts []GeneratorOption, count int, shape []int, nvars int, lens ...int) error {
+	o := applyOptions(opts)
+
+	if !slices.Equal(st.Shape, shape) || st.Gray != o.gray || st.Lex != o.lex || st.Reverse != o.reverse {
+		return fmt.Errorf("state doesn't match the generator")
+	}
+
+	if checkRange(st.From, st.To, count) != nil || st.Pos < st.From-1 || st.Pos > st.To ||
+		st.Change.Kind < ChangeNone || st.Change.Kind > ChangeRemove {
+		return fmt.Errorf("invalid state data")
+	}
+
+	if len(st.Vars) != nvars || len(st.Slices) != len(lens) {
+		return fmt.Errorf("invalid state data")
+	}
+
+	for i, l := range lens {
+		if len(st.Slices[i]) != l {
+			return fmt.Errorf("invalid state data")
+		}
+	}
+
+	return nil
+}
+
+// Reports if all values in s are in the range [lo, hi].
+func inRange(s []int, lo, hi int) bool {
+	for _, v := range s {
+		if v < lo || v > hi {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reports if s holds every position of a multiset with the given counts of elements
+// as many times as its count.
+func isMultiset(s, counts []int) bool {
+	seen := make([]int, len(counts))
+
+	for _, v := range s {
+		if v < 0 || v >= len(counts) {
+			return false
+		}
+
+		if seen[v]++; seen[v] > counts[v] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Converts a flag of the algorithm to a variable of the state.
+func boolVar(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,303 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"math"
+	"slices"
+	"testing"
+)
+
+type stateGenerator[T any] interface {
+	SeekableGenerator[T]
+	LastChange() Change
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+	json.Marshaler
+	json.Unmarshaler
+}
+
+// Checks that the state of gen saved at every position, with and without a range,
+// restores into a generator created by factory and continues the same sequence.
+func testState[T cmp.Ordered](t *testing.T, factory func() stateGenerator[T]) {
+	t.Helper()
+
+	want := make([][]T, 0)
+
+	for gen := factory(); gen.Next(); {
+		want = append(want, gen.CurrentCopy())
+	}
+
+	for _, shard := range [][2]int{{0, 1}, {1, 2}} {
+		for stop := 0; stop <= len(want); stop++ {
+			gen := factory()
+			gen.Shard(shard[0], shard[1])
+			from := gen.Position() + 1
+
+			for i := 0; i < stop && gen.Next(); i++ {
+			}
+
+			bin, err := gen.MarshalBinary()
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			js, err := json.Marshal(gen)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			for name, load := range map[string]func(stateGenerator[T]) error{
+				"binary": func(g stateGenerator[T]) error { return g.UnmarshalBinary(bin) },
+				"json":   func(g stateGenerator[T]) error { return json.Unmarshal(js, g) },
+			} {
+				res := factory()
+
+				if err := load(res); err != nil {
+					t.Fatalf("Error'd with (%v): %v", name, err)
+				}
+
+				if res.Position() != gen.Position() {
+					t.Fatalf("Wrong position (%v), got: %v, want: %v", name, res.Position(), gen.Position())
+				}
+
+				if res.LastChange() != gen.LastChange() {
+					t.Fatalf("Wrong change (%v), got: %v, want: %v", name, res.LastChange(), gen.LastChange())
+				}
+
+				if p := res.Position(); p >= from && slices.Compare(res.Current(), want[p]) != 0 {
+					t.Errorf("Not equal (%v) at %v, \ngot: %v, \nwant: %v", name, p, res.Current(), want[p])
+				}
+
+				for {
+					ok, rok := gen.Next(), res.Next()
+
+					if ok != rok {
+						t.Fatalf("Different end (%v) at %v after stop at %v", name, res.Position(), stop)
+					}
+					if !ok {
+						break
+					}
+					if slices.Compare(res.Current(), want[res.Position()]) != 0 {
+						t.Fatalf("Not equal (%v) at %v, \ngot: %v, \nwant: %v", name, res.Position(), res.Current(), want[res.Position()])
+					}
+				}
+
+				// restore the original generator for the other encoding
+				gen.UnmarshalBinary(bin)
+			}
+		}
+	}
+}
+
+func TestGeneratorState(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+
+	testState(t, func() stateGenerator[int] {
+		gen, _ := NewCombinationGenerator(2, items)
+		return gen
+	})
+	testState(t, func() stateGenerator[int] {
+		gen, _ := NewPermutationGenerator(items)
+		return gen
+	})
+	testState(t, func() stateGenerator[int] {
+		gen, _ := NewMultiPermutationGenerator(items[:3], []int{2, 1, 1})
+		return gen
+	})
+	testState(t, func() stateGenerator[int] {
+		gen, _ := NewVariationGenerator(2, items)
+		return gen
+	})
+	testState(t, func() stateGenerator[int] {
+		gen, _ := NewVariationIndexGenerator(3, 2)
+		return gen
+	})
+	testState(t, func() stateGenerator[int] {
+		gen, _ := NewProductGenerator([][]int{items[:2], items[:3], items[:1]})
+		return gen
+	})
+
+	for _, opts := range [][]GeneratorOption{{Lexicographic()}, {Gray()}, {Reverse()}} {
+		o := applyOptions(opts)
+
+		if !o.gray {
+			testState(t, func() stateGenerator[int] {
+				gen, _ := NewCombinationGenerator(2, items, opts...)
+				return gen
+			})
+			testState(t, func() stateGenerator[int] {
+				gen, _ := NewMultiPermutationGenerator(items[:3], []int{2, 1, 1}, opts...)
+				return gen
+			})
+			testState(t, func() stateGenerator[int] {
+				gen, _ := NewMultiPermutationGenerator(items[:1], []int{3}, opts...)
+				return gen
+			})
+			testState(t, func() stateGenerator[int] {
+				gen, _ := NewProductGenerator([][]int{items[:2], items[:3]}, opts...)
+				return gen
+			})
+		}
+
+		testState(t, func() stateGenerator[int] {
+			gen, _ := NewPermutationGenerator(items, opts...)
+			return gen
+		})
+		testState(t, func() stateGenerator[int] {
+			gen, _ := NewVariationGenerator(2, items[:3], opts...)
+			return gen
+		})
+	}
+}
+
+func TestGeneratorStateLarge(t *testing.T) {
+	data := []struct {
+		name    string
+		count   int
+		factory func() stateGenerator[int]
+	}{
+		{"permutations", PermutationCount(20), func() stateGenerator[int] {
+			gen, _ := NewPermutationIndexGenerator(20)
+			return gen
+		}},
+		// sequences longer than math.MaxInt can be restored within their first math.MaxInt results
+		{"permutations lex", math.MaxInt, func() stateGenerator[int] {
+			gen, _ := NewPermutationIndexGenerator(21, Lexicographic())
+			return gen
+		}},
+		{"combinations", math.MaxInt, func() stateGenerator[int] {
+			gen, _ := NewCombinationIndexGenerator(50, 100)
+			return gen
+		}},
+		{"multiset permutations", math.MaxInt, func() stateGenerator[int] {
+			gen, _ := NewMultiPermutationIndexGenerator(slices.Repeat([]int{3}, 10))
+			return gen
+		}},
+		{"variations", math.MaxInt, func() stateGenerator[int] {
+			gen, _ := NewVariationIndexGenerator(70, 2)
+			return gen
+		}},
+	}
+
+	for _, d := range data {
+		t.Run(d.name, func(t *testing.T) {
+			gen := d.factory()
+
+			for _, rank := range []int{1, d.count / 2, d.count - 3} {
+				if err := gen.Seek(rank); err != nil || !gen.Next() {
+					t.Fatalf("Didn't move to %v: %v", rank, err)
+				}
+
+				bin, _ := gen.MarshalBinary()
+				js, _ := gen.MarshalJSON()
+				res, jres := d.factory(), d.factory()
+
+				if err := res.UnmarshalBinary(bin); err != nil {
+					t.Fatalf("Error'd with (binary): %v", err)
+				}
+				if err := jres.UnmarshalJSON(js); err != nil {
+					t.Fatalf("Error'd with (json): %v", err)
+				}
+
+				for _, r := range []stateGenerator[int]{res, jres} {
+					if r.Position() != rank || !slices.Equal(r.Current(), gen.Current()) {
+						t.Fatalf("Not equal at %v, \ngot: %v (%v), \nwant: %v", rank, r.Current(), r.Position(), gen.Current())
+					}
+				}
+
+				gen.Next()
+
+				for _, r := range []stateGenerator[int]{res, jres} {
+					if !r.Next() || !slices.Equal(r.Current(), gen.Current()) {
+						t.Fatalf("Not continued after %v, \ngot: %v, \nwant: %v", rank, r.Current(), gen.Current())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorStateErrors(t *testing.T) {
+	gen, _ := NewCombinationGenerator(2, []int{1, 2, 3, 4})
+	gen.Next()
+	bin, _ := gen.MarshalBinary()
+	js, _ := gen.MarshalJSON()
+
+	other, _ := NewCombinationGenerator(2, []int{1, 2, 3})
+
+	if err := other.UnmarshalBinary(bin); err == nil {
+		t.Errorf("Didn't err on a different input (binary)")
+	}
+	if err := other.UnmarshalJSON(js); err == nil {
+		t.Errorf("Didn't err on a different input (json)")
+	}
+
+	for i, data := range [][]byte{nil, {0}, bin[:len(bin)-1], append(slices.Clone(bin), 0)} {
+		if err := gen.UnmarshalBinary(data); err == nil {
+			t.Errorf("Didn't err on invalid data %v", i)
+		}
+	}
+
+	for i, data := range []string{
+		`{"shape":[2,4],"pos":7,"from":0,"to":6,"vars":[5,0,0,0,0,0],"slices":[[5,0,0,1,2,-2]]}`,
+		`{"shape":[2,4],"pos":0,"from":2,"to":6,"vars":[5,0,0,0,0,0],"slices":[[5,0,0,1,2,-2]]}`,
+		`{"shape":[2,4],"pos":0,"from":0,"to":7,"vars":[5,0,0,0,0,0],"slices":[[5,0,0,1,2,-2]]}`,
+		`{"shape":[2,4],"pos":0,"from":0,"to":6,"vars":[5,0,0,0,0],"slices":[[5,0,0,1,2,-2]]}`,
+		`{"shape":[2,4],"pos":0,"from":0,"to":6,"vars":[9,0,0,0,0,0],"slices":[[5,0,0,1,2,-2]]}`,
+		`{"shape":[2,4],"pos":0,"from":0,"to":6,"vars":[5,0,0,0,0,0],"slices":[[5,0,0,1,2]]}`,
+		`{"shape":[2,4],"pos":0,"from":0,"to":6,"vars":[5,0,0,0,0,0],"slices":[[5,0,0,1,1,-2]]}`,
+		`{"shape":[2,4],"pos":0,"from":0,"to":6,"vars":[5,0,0,0,0,0],"slices":[[5,0,1,1,2,-2]]}`,
+		`{"shape":[2,4],"pos":0,"from":0,"to":6,"change":{"Kind":9},"vars":[5,0,0,0,0,0],"slices":[[5,0,0,1,2,-2]]}`,
+	} {
+		if err := gen.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("Didn't err on invalid data %v", i)
+		}
+	}
+
+	want := `{"shape":[2,4],"gray":false,"lex":false,"reverse":false,"pos":0,"from":0,"to":6,` +
+		`"change":{"Kind":1,"I":0,"J":0,"Old":0,"New":0},"vars":[5,0,0,0,0,0],"slices":[[5,0,0,1,2,-2]]}`
+
+	if s := string(js); s != want {
+		t.Errorf("Unexpected JSON: %v", s)
+	}
+}
+
+func TestGeneratorStateOptions(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+
+	for _, opts := range [][]GeneratorOption{{Lexicographic()}, {Gray()}, {Reverse()}} {
+		gen, _ := NewPermutationGenerator(items, opts...)
+		gen.Prev()
+		gen.Prev()
+		bin, _ := gen.MarshalBinary()
+		js, _ := gen.MarshalJSON()
+
+		other, _ := NewPermutationGenerator(items)
+
+		if err := other.UnmarshalBinary(bin); err == nil {
+			t.Errorf("Didn't err on different options %v (binary)", applyOptions(opts))
+		}
+		if err := other.UnmarshalJSON(js); err == nil {
+			t.Errorf("Didn't err on different options %v (json)", applyOptions(opts))
+		}
+
+		bin, _ = other.MarshalBinary()
+		js, _ = other.MarshalJSON()
+		gen, _ = NewPermutationGenerator(items, opts...)
+
+		if err := gen.UnmarshalBinary(bin); err == nil {
+			t.Errorf("Didn't err on missing options %v (binary)", applyOptions(opts))
+		}
+		if err := gen.UnmarshalJSON(js); err == nil {
+			t.Errorf("Didn't err on missing options %v (json)", applyOptions(opts))
+		}
+	}
+}
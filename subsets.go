@@ -0,0 +1,552 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"math/big"
+	"math/bits"
+	"slices"
+)
+
+// SubsetCount returns the number of subsets of n elements with sizes in the range [minK, maxK].
+func SubsetCount(minK, maxK, n int) int {
+	ret := 0
+
+	for k := max(minK, 0); k <= min(maxK, n); k++ {
+		ret += Binom(k, n)
+	}
+
+	return ret
+}
+
+// SubsetCountChecked returns the number of subsets of n elements with sizes in the range
+// [minK, maxK], or [ErrOverflow] if it doesn't fit in an int.
+func SubsetCountChecked(minK, maxK, n int) (int, error) {
+	ret := 0
+
+	for k := max(minK, 0); k <= min(maxK, n); k++ {
+		c, err := BinomChecked(k, n)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if ret += c; ret < 0 {
+			return 0, ErrOverflow
+		}
+	}
+
+	return ret, nil
+}
+
+// SubsetCountBig returns the number of subsets of n elements with sizes in the range
+// [minK, maxK] as [big.Int].
+func SubsetCountBig(minK, maxK, n int) *big.Int {
+	ret := big.NewInt(0)
+
+	for k := max(minK, 0); k <= min(maxK, n); k++ {
+		ret.Add(ret, BinomBig(k, n))
+	}
+
+	return ret
+}
+
+// Subsets returns all subsets of elements in the elems slice with sizes in the range
+// [minK, maxK], ordered by size and then lexicographically by positions in elems.
+// Elements in every subset are ordered by their position in elems.
+//
+// Returns an error if elems is empty or nil, if minK < 0, if maxK > len(elems),
+// or if minK > maxK.
+func Subsets[T any](minK, maxK int, elems []T) ([][]T, error) {
+	return SubsetsWithLimit(minK, maxK, elems)
+}
+
+// SubsetsWithLimit is like [Subsets], but returns [TooManyResultsError] before allocating
+// the results if there are more of them than allowed by opts.
+func SubsetsWithLimit[T any](minK, maxK int, elems []T, opts ...LimitOption) ([][]T, error) {
+	gen, err := NewSubsetGenerator(minK, maxK, elems)
+
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(elems)
+	count, err := SubsetCountChecked(minK, maxK, n)
+
+	if err := checkLimit[T](count, err, maxK, opts, func() *big.Int { return SubsetCountBig(minK, maxK, n) }); err != nil {
+		return nil, err
+	}
+
+	// the limit counts the header of every result, so with zero-sized elements,
+	// the total number of elements can still overflow
+	size := 0
+
+	for k := minK; k <= maxK; k++ {
+		s, ok := mul(k, Binom(k, n))
+
+		if !ok || size > math.MaxInt-s {
+			return nil, &TooManyResultsError{Count: SubsetCountBig(minK, maxK, n), Limit: resultLimit[T](maxK, opts)}
+		}
+
+		size += s
+	}
+
+	data := make([]T, size)
+	res := make([][]T, 0, count)
+
+	for gen.Next() {
+		k := len(gen.Current())
+		row := data[:k:k]
+		copy(row, gen.Current())
+		res = append(res, row)
+		data = data[k:]
+	}
+
+	return res, nil
+}
+
+// SubsetGenerator implements a [Generator] interface for generating subsets
+// of elements in the input slice. The slice returned by [SubsetGenerator.Current]
+// has the length of the current subset.
+type SubsetGenerator[T any] struct {
+	n, minK, maxK, k, pos, from, to int
+	p, idx                          []int
+	in                              []bool
+	elems, dest                     []T
+	opts                            []GeneratorOption
+	gray, first, reverse            bool
+	change                          Change
+}
+
+// Init initializes a generator for subsets of elements in the elems slice with sizes
+// in the range [minK, maxK]. Subsets are ordered by size and then lexicographically
+// by positions in elems, and elements in every subset are ordered by their position.
+//
+// Supported options are [Gray] and [Reverse]. With the [Gray] option, subsets are produced in the binary reflected Gray code order,
+// starting with the empty set, where every subset differs from the previous one by
+// exactly one added or removed element. The option requires the full range of sizes,
+// i.e. minK = 0 and maxK = len(elems), and len(elems) less than the number of bits
+// in an int.
+//
+// Returns an error if elems is empty or nil, if minK < 0, if maxK > len(elems),
+// if minK > maxK, or if the options can't be applied.
+func (gen *SubsetGenerator[T]) Init(minK, maxK int, elems []T, opts ...GeneratorOption) error {
+	if len(elems) == 0 {
+		return fmt.Errorf("input slice is nil or empty")
+	}
+
+	return gen.init(minK, maxK, len(elems), elems, opts)
+}
+
+// Initializes the generator for subsets of n elements with sizes in the range [minK, maxK].
+// If elems is nil, the generator is in index mode and produces positions instead of elements,
+// which requires T to be int.
+func (gen *SubsetGenerator[T]) init(minK, maxK, n int, elems []T, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	switch {
+	case minK < 0:
+		return fmt.Errorf("minK must be >= 0")
+	case maxK > n:
+		return fmt.Errorf("maxK is too large")
+	case minK > maxK:
+		return fmt.Errorf("minK is bigger than maxK")
+	case o.gray && (minK != 0 || maxK != n):
+		return fmt.Errorf("gray code order requires all sizes of subsets")
+	case o.gray && n >= bits.UintSize-1:
+		return fmt.Errorf("too many elements for gray code order")
+	case o.lex:
+		return fmt.Errorf("lexicographic order is not supported")
+	}
+
+	if maxK != gen.maxK {
+		gen.dest = make([]T, maxK)
+		gen.p = make([]int, maxK)
+	}
+
+	if o.gray && len(gen.in) != n {
+		gen.in = make([]bool, n)
+	}
+
+	gen.n = n
+	gen.minK, gen.maxK = minK, maxK
+	gen.elems = elems
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.dest).([]int)
+	}
+
+	gen.opts = opts
+	gen.gray = o.gray
+	gen.reverse = o.reverse
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(0)
+}
+
+// Returns the number of subsets in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *SubsetGenerator[T]) count() int {
+	return saturate(SubsetCountChecked(gen.minK, gen.maxK, gen.n))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
+func (gen *SubsetGenerator[T]) Reset() {
+	s, from, to := gen.dest, gen.from, gen.to
+	gen.init(gen.minK, gen.maxK, gen.n, gen.elems, gen.opts)
+	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
+}
+
+// Current returns the internal slice that holds the current subset.
+// If you need to modify the returned slice, use [SubsetGenerator.CurrentCopy] instead.
+func (gen *SubsetGenerator[T]) Current() []T {
+	return gen.dest[:gen.k]
+}
+
+// CurrentCopy returns a copy of the internal slice that holds the current subset.
+// If you don't need to modify the returned slice, use [SubsetGenerator.Current] to avoid allocation.
+func (gen *SubsetGenerator[T]) CurrentCopy() []T {
+	return slices.Clone(gen.dest[:gen.k])
+}
+
+// SetDest sets a destination slice that will receive the results. The slice needs
+// the capacity for the largest subset. Returns an error if there's not enough capacity in the slice.
+//
+// After the destination slice is set, subsequent calls to [SubsetGenerator.Current]
+// will return the provided slice, resliced to the length of the current subset.
+func (gen *SubsetGenerator[T]) SetDest(dest []T) error {
+	if got := cap(dest); got < gen.maxK {
+		return fmt.Errorf(capacityMsg(gen.maxK, got))
+	}
+
+	dest = dest[:gen.maxK]
+	copy(dest, gen.dest)
+	gen.dest = dest
+
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
+	return nil
+}
+
+// Next produces a new subset in the generator. If it returns false,
+// there are no more subsets available.
+func (gen *SubsetGenerator[T]) Next() bool {
+	if gen.n == 0 || gen.pos+1 >= gen.to {
+		return false
+	}
+
+	switch {
+	case gen.first:
+		gen.first = false
+		gen.change = Change{Kind: ChangeAll}
+	case gen.gray:
+		gen.toggle(bits.TrailingZeros(uint(gen.pos + 1)))
+	default:
+		k := gen.k
+		i := k - 1
+
+		for i >= 0 && gen.p[i] == gen.n-k+i {
+			i--
+		}
+
+		if i < 0 {
+			// continue with the first subset of the next size
+			gen.k++
+			gen.change = Change{Kind: ChangeAll}
+
+			for j := 0; j < gen.k; j++ {
+				gen.p[j] = j
+				gen.set(j, j)
+			}
+		} else {
+			gen.change = rangeChange(i, k, gen.p[i], gen.p[i]+1)
+			gen.p[i]++
+			gen.set(i, gen.p[i])
+
+			for j := i + 1; j < k; j++ {
+				gen.p[j] = gen.p[j-1] + 1
+				gen.set(j, gen.p[j])
+			}
+		}
+	}
+
+	gen.pos++
+
+	return true
+}
+
+// Adds the element at position b to the current subset, or removes it if it's
+// already in the subset, keeping elements ordered by their positions.
+func (gen *SubsetGenerator[T]) toggle(b int) {
+	i, _ := slices.BinarySearch(gen.p[:gen.k], b)
+
+	if gen.in[b] {
+		gen.change = Change{Kind: ChangeRemove, I: i, Old: b}
+		copy(gen.p[i:], gen.p[i+1:gen.k])
+		copy(gen.dest[i:], gen.dest[i+1:gen.k])
+		gen.k--
+	} else {
+		copy(gen.p[i+1:gen.k+1], gen.p[i:gen.k])
+		copy(gen.dest[i+1:gen.k+1], gen.dest[i:gen.k])
+		gen.change = Change{Kind: ChangeInsert, I: i, New: b}
+		gen.p[i] = b
+		gen.set(i, b)
+		gen.k++
+	}
+
+	gen.in[b] = !gen.in[b]
+}
+
+// Prev produces the subset before the current one in the sequence of the generator.
+// If it returns false, there are no previous subsets available in the range.
+// In the Gray code order, it undoes the last step, so it also reports the change
+// by [SubsetGenerator.LastChange]. Otherwise, Prev seeks to the previous subset, which
+// takes as much time as [SubsetGenerator.Seek], and the change is reported as [ChangeAll].
+func (gen *SubsetGenerator[T]) Prev() bool {
+	if !gen.gray || gen.change.Kind == ChangeNone {
+		return prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	if gen.pos-1 < gen.from {
+		return false
+	}
+
+	gen.toggle(bits.TrailingZeros(uint(gen.pos)))
+	gen.pos--
+
+	return true
+}
+
+// LastChange returns the change of the current subset made by the last call to
+// [SubsetGenerator.Next]. In the Gray code order, every subset after the first one
+// differs from the previous one by an added ([ChangeInsert]) or a removed ([ChangeRemove])
+// element. Otherwise, subsets of the same size differ in the last element ([ChangeReplace]),
+// or in elements from some position to the end ([ChangeRange]), and the first subset of
+// every size is reported as [ChangeAll].
+func (gen *SubsetGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current subset in the sequence of the generator,
+// or -1 if [SubsetGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [SubsetGenerator.Prev] is called.
+func (gen *SubsetGenerator[T]) Position() int {
+	return gen.pos
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [SubsetGenerator.Next] produces the subset at that rank,
+// and [SubsetGenerator.Prev] the one before it.
+// Seeking to the end of the range set by [SubsetGenerator.Range]
+// (SubsetCount(minK, maxK, n) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *SubsetGenerator[T]) Seek(rank int) error {
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.first = rank < gen.count()
+	gen.pos = rank - 1
+
+	if !gen.first {
+		rank--
+	}
+
+	if gen.gray {
+		g := uint(rank ^ (rank >> 1))
+		gen.k = 0
+
+		for b := 0; b < gen.n; b++ {
+			if gen.in[b] = g&(1<<b) != 0; gen.in[b] {
+				gen.p[gen.k] = b
+				gen.k++
+			}
+		}
+	} else {
+		gen.k = gen.minK
+
+		for c := saturate(BinomChecked(gen.k, gen.n)); rank >= c; c = saturate(BinomChecked(gen.k, gen.n)) {
+			rank -= c
+			gen.k++
+		}
+
+		combinationUnrank(gen.k, gen.n, rank, gen.p)
+	}
+
+	for i, p := range gen.p[:gen.k] {
+		gen.set(i, p)
+	}
+
+	return nil
+}
+
+// Writes the element at position x into the position i of the current subset.
+func (gen *SubsetGenerator[T]) set(i, x int) {
+	if gen.idx != nil {
+		gen.idx[i] = x
+	} else {
+		gen.dest[i] = gen.elems[x]
+	}
+}
+
+// Range limits the generator to the subsets with ranks in the range [from, to)
+// and moves it to the rank from. [SubsetGenerator.Reset] preserves the range, and
+// [SubsetGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, SubsetCount(minK, maxK, n)].
+func (gen *SubsetGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *SubsetGenerator[T]) end() {
+	gen.pos = gen.to
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *SubsetGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// All returns an iterator over all subsets paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [SubsetGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded subset and can be resumed with [SubsetGenerator.Next] or restarted with
+// [SubsetGenerator.Reset].
+func (gen *SubsetGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [SubsetGenerator.All], but it yields only the subsets.
+func (gen *SubsetGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following subsets, as if by calling
+// [SubsetGenerator.Next] and [SubsetGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of subsets written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *SubsetGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [SubsetGenerator.NextBatch], but writes the subsets one after another
+// into dst, where every subset takes maxK elements, and elements after the end of a smaller
+// subset are set to the zero value. Writes as many subsets as fit into dst. With maxK = 0,
+// the empty subset takes no elements, so it's counted even if dst is empty.
+// Use [SubsetGenerator.NextBatch] if you need sizes of the subsets.
+func (gen *SubsetGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.maxK)
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current subset, and shares only the input with the original.
+func (gen *SubsetGenerator[T]) Clone() *SubsetGenerator[T] {
+	c := *gen
+	c.dest = slices.Clone(gen.dest)
+	c.p = slices.Clone(gen.p)
+	c.in = slices.Clone(gen.in)
+
+	if gen.idx != nil {
+		c.idx = any(c.dest).([]int)
+	}
+
+	return &c
+}
+
+// NewSubsetGenerator creates and initializes a new SubsetGenerator.
+// Arguments and returned errors are the same ones from the [SubsetGenerator.Init] method.
+func NewSubsetGenerator[T any](minK, maxK int, elems []T, opts ...GeneratorOption) (*SubsetGenerator[T], error) {
+	gen := new(SubsetGenerator[T])
+	err := gen.Init(minK, maxK, elems, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// NewSubsetIndexGenerator creates a new SubsetGenerator that produces subsets with sizes
+// in the range [minK, maxK] as positions of elements in an implicit input of size n, i.e.
+// as integers in the range [0, n), without requiring the input slice. Returns an error if n < 1,
+// otherwise the same errors as [SubsetGenerator.Init].
+func NewSubsetIndexGenerator(minK, maxK, n int, opts ...GeneratorOption) (*SubsetGenerator[int], error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be >= 1")
+	}
+
+	gen := new(SubsetGenerator[int])
+
+	if err := gen.init(minK, maxK, n, nil, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// SubsetsSeq returns an iterator over subsets of elements in the elems slice with sizes
+// in the range [minK, maxK], paired with their rank. The sequence is empty if the arguments
+// are invalid, see [SubsetGenerator.Init] for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func SubsetsSeq[T any](minK, maxK int, elems []T) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewSubsetGenerator(minK, maxK, elems)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
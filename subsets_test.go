@@ -0,0 +1,315 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+var (
+	_subset_data = []struct {
+		input      []int
+		want       [][]int
+		minK, maxK int
+	}{
+		{
+			input: []int{1},
+			want:  [][]int{{}, {1}},
+			minK:  0,
+			maxK:  1,
+		},
+		{
+			input: []int{1, 2, 3},
+			want: [][]int{
+				{},
+				{1},
+				{2},
+				{3},
+				{1, 2},
+				{1, 3},
+				{2, 3},
+				{1, 2, 3},
+			},
+			minK: 0,
+			maxK: 3,
+		},
+		{
+			input: []int{1, 2, 3, 4},
+			want: [][]int{
+				{1, 2},
+				{1, 3},
+				{1, 4},
+				{2, 3},
+				{2, 4},
+				{3, 4},
+				{1, 2, 3},
+				{1, 2, 4},
+				{1, 3, 4},
+				{2, 3, 4},
+			},
+			minK: 2,
+			maxK: 3,
+		},
+		{
+			input: []int{1, 2, 3},
+			want:  [][]int{{}},
+			minK:  0,
+			maxK:  0,
+		},
+	}
+
+	_subset_gray = [][]int{
+		{},
+		{1},
+		{1, 2},
+		{2},
+		{2, 3},
+		{1, 2, 3},
+		{1, 3},
+		{3},
+	}
+)
+
+func TestSubsets(t *testing.T) {
+	for _, d := range _subset_data {
+		d := d
+
+		t.Run(fmt.Sprintf("s(%d..%d,%d)", d.minK, d.maxK, len(d.input)), func(t *testing.T) {
+			res, err := Subsets(d.minK, d.maxK, d.input)
+
+			if err != nil {
+				t.Errorf("Error'd with: %v", err)
+			}
+
+			if compareSliceOfSlices(res, d.want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, d.want)
+			}
+
+			for _, r := range res {
+				if cap(r) != len(r) {
+					t.Errorf("Subset %v has capacity %d", r, cap(r))
+				}
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := Subsets(-1, 1, []int{1}); err == nil {
+			t.Errorf("Didn't err on minK < 0")
+		}
+		if _, err := Subsets(0, 2, []int{1}); err == nil {
+			t.Errorf("Didn't err on maxK > n")
+		}
+		if _, err := Subsets(1, 0, []int{1}); err == nil {
+			t.Errorf("Didn't err on minK > maxK")
+		}
+		if _, err := Subsets(0, 0, []int{}); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+		if _, err := SubsetsWithLimit(0, 3, []int{1, 2, 3}, MaxResults(7)); !errors.Is(err, ErrTooManyResults) {
+			t.Errorf("Want ErrTooManyResults, got %v", err)
+		}
+		// the number of results fits, but not the number of their elements
+		if _, err := Subsets(28, 28, make([]struct{}, 62)); !errors.Is(err, ErrTooManyResults) {
+			t.Errorf("Want ErrTooManyResults, got %v", err)
+		}
+	})
+}
+
+func TestSubsetCount(t *testing.T) {
+	if n := SubsetCount(0, 10, 10); n != 1024 {
+		t.Errorf("Want 1024, got %v", n)
+	}
+	if n := SubsetCount(2, 3, 4); n != 10 {
+		t.Errorf("Want 10, got %v", n)
+	}
+	if _, err := SubsetCountChecked(0, 64, 64); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Want ErrOverflow, got %v", err)
+	}
+	if n := SubsetCountBig(0, 64, 64).String(); n != "18446744073709551616" {
+		t.Errorf("Want 18446744073709551616, got %v", n)
+	}
+}
+
+func TestSubsetGenerator(t *testing.T) {
+	for _, d := range _subset_data {
+		d := d
+
+		t.Run(fmt.Sprintf("s(%d..%d,%d)", d.minK, d.maxK, len(d.input)), func(t *testing.T) {
+			gen, err := NewSubsetGenerator(d.minK, d.maxK, d.input)
+
+			if err != nil {
+				t.Fatalf("Error'd with: %v", err)
+			}
+
+			testGenerator[int](t, gen, d.want)
+		})
+	}
+
+	items := []int{1, 2, 3, 4, 5, 6}
+
+	for minK := 0; minK <= len(items); minK++ {
+		for maxK := minK; maxK <= len(items); maxK++ {
+			want := make([][]int, 0)
+
+			for k := max(minK, 1); k <= maxK; k++ {
+				for _, v := range CombinationsSeq(k, items) {
+					want = append(want, slices.Clone(v))
+				}
+
+				slices.SortFunc(want[len(want)-CombinationCount(k, len(items)):], slices.Compare)
+			}
+
+			if minK == 0 {
+				want = append([][]int{{}}, want...)
+			}
+
+			gen, _ := NewSubsetGenerator(minK, maxK, items)
+			testGenerator[int](t, gen, want)
+		}
+	}
+}
+
+func TestSubsetGeneratorEmpty(t *testing.T) {
+	gen, _ := NewSubsetGenerator(0, 0, []int{1, 2})
+
+	if n := gen.NextBatch(make([][]int, 2)); n != 1 {
+		t.Errorf("Wrong number of subsets (batch), got: %v, want: 1", n)
+	}
+
+	gen.Reset()
+
+	if n := gen.NextBatchFlat(nil); n != 1 {
+		t.Errorf("Wrong number of subsets (flat), got: %v, want: 1", n)
+	}
+
+	if n := gen.NextBatchFlat(nil); n != 0 {
+		t.Errorf("Wrote %v subsets after the end", n)
+	}
+}
+
+func TestSubsetGeneratorGray(t *testing.T) {
+	gen, err := NewSubsetGenerator(0, 3, []int{1, 2, 3}, Gray())
+
+	if err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	testGenerator[int](t, gen, _subset_gray)
+
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	gen, _ = NewSubsetGenerator(0, len(items), items, Gray())
+	seen := map[string]bool{}
+	prev := []int{}
+
+	for gen.Next() {
+		c := gen.Current()
+
+		if !slices.IsSorted(c) {
+			t.Errorf("Not in order: %v", c)
+		}
+
+		// the symmetric difference of consecutive subsets has exactly one element
+		diff := 0
+
+		for _, v := range c {
+			if !slices.Contains(prev, v) {
+				diff++
+			}
+		}
+		for _, v := range prev {
+			if !slices.Contains(c, v) {
+				diff++
+			}
+		}
+
+		if diff != 1 && gen.Position() > 0 {
+			t.Errorf("Subsets %v and %v differ by %d elements", prev, c, diff)
+		}
+
+		seen[fmt.Sprint(c)] = true
+		prev = gen.CurrentCopy()
+	}
+
+	if len(seen) != 1<<len(items) {
+		t.Errorf("Want %d distinct subsets, got %d", 1<<len(items), len(seen))
+	}
+
+	if _, err := NewSubsetGenerator(1, 3, []int{1, 2, 3}, Gray()); err == nil {
+		t.Errorf("Didn't err on gray code order with restricted sizes")
+	}
+}
+
+func TestSubsetIndexGenerator(t *testing.T) {
+	gen, err := NewSubsetIndexGenerator(1, 2, 4)
+
+	if err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	want, _ := Subsets(1, 2, indices(4))
+	testGenerator[int](t, gen, want)
+
+	if gen, err = NewSubsetIndexGenerator(0, 3, 3, Gray()); err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	want = make([][]int, len(_subset_gray))
+
+	for i, s := range _subset_gray {
+		for _, v := range s {
+			want[i] = append(want[i], v-1)
+		}
+	}
+
+	testGenerator[int](t, gen, want)
+
+	if _, err := NewSubsetIndexGenerator(0, 0, 0); err == nil {
+		t.Errorf("Didn't err on n = 0")
+	}
+}
+
+func TestSubsetsSeq(t *testing.T) {
+	for _, d := range _subset_data {
+		res := make([][]int, 0, len(d.want))
+
+		for _, v := range SubsetsSeq(d.minK, d.maxK, d.input) {
+			res = append(res, slices.Clone(v))
+		}
+
+		if compareSliceOfSlices(res, d.want) != 0 {
+			t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, d.want)
+		}
+	}
+
+	for range SubsetsSeq(2, 1, []int{1, 2}) {
+		t.Errorf("Didn't return an empty sequence on invalid input")
+	}
+}
+
+func BenchmarkSubsetGenerator(b *testing.B) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	for _, gray := range []bool{false, true} {
+		gen := new(SubsetGenerator[int])
+		opts := []GeneratorOption{}
+
+		if gray {
+			opts = append(opts, Gray())
+		}
+
+		b.Run(fmt.Sprintf("s(10),gray=%v", gray), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				gen.Init(0, len(items), items, opts...)
+				for gen.Next() {
+					gen.Current()
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+// Table holds a list of results of the same width in one contiguous slice.
+type Table[T any] struct {
+	data  []T
+	width int
+}
+
+// NewTable creates a table of n rows of the given width.
+func NewTable[T any](n, width int) *Table[T] {
+	return &Table[T]{data: make([]T, n*width), width: width}
+}
+
+// Len returns the number of rows in the table.
+func (t *Table[T]) Len() int {
+	if t.width == 0 {
+		return 0
+	}
+
+	return len(t.data) / t.width
+}
+
+// Width returns the length of every row in the table.
+func (t *Table[T]) Width() int {
+	return t.width
+}
+
+// Row returns the i-th row of the table. The row shares memory with the table,
+// and its capacity is limited to its length, so appending to it doesn't
+// overwrite the next row.
+func (t *Table[T]) Row(i int) []T {
+	return t.data[i*t.width : (i+1)*t.width : (i+1)*t.width]
+}
+
+// Data returns the slice holding all rows of the table one after another.
+func (t *Table[T]) Data() []T {
+	return t.data
+}
+
+// Rows returns all rows of the table as a slice of slices, each of them returned by [Table.Row].
+func (t *Table[T]) Rows() [][]T {
+	rows := make([][]T, t.Len())
+
+	for i := range rows {
+		rows[i] = t.Row(i)
+	}
+
+	return rows
+}
+
+// Creates a table of count rows of the given width, filled with results of the generator.
+func fillTable[T any](gen Generator[T], count, width int) *Table[T] {
+	t := NewTable[T](count, width)
+
+	for i := 0; i < count && gen.Next(); i++ {
+		copy(t.Row(i), gen.Current())
+	}
+
+	return t
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Dražen Golić. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package kombinat
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	tab := NewTable[int](3, 2)
+
+	if tab.Len() != 3 || tab.Width() != 2 || len(tab.Data()) != 6 {
+		t.Fatalf("Wrong dimensions: %d x %d", tab.Len(), tab.Width())
+	}
+
+	copy(tab.Data(), []int{1, 2, 3, 4, 5, 6})
+
+	if r := tab.Row(1); slices.Compare(r, []int{3, 4}) != 0 || cap(r) != 2 {
+		t.Errorf("Not equal, \ngot: %v (cap %d), \nwant: [3 4] (cap 2)", r, cap(r))
+	}
+
+	rows := tab.Rows()
+
+	if compareSliceOfSlices(rows, [][]int{{1, 2}, {3, 4}, {5, 6}}) != 0 {
+		t.Errorf("Not equal, \ngot: %v", rows)
+	}
+
+	rows[0][1] = 7
+	_ = append(rows[0], 8)
+
+	if slices.Compare(tab.Data(), []int{1, 7, 3, 4, 5, 6}) != 0 {
+		t.Errorf("Rows don't alias the table properly, got: %v", tab.Data())
+	}
+
+	if tab := NewTable[int](0, 0); tab.Len() != 0 || len(tab.Rows()) != 0 {
+		t.Errorf("Empty table isn't empty")
+	}
+}
+
+// Checks that whole-result functions return the same results as their table counterparts,
+// and that the number of allocations doesn't depend on the number of results.
+func TestTableFunctions(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6}
+
+	cases := []struct {
+		name  string
+		table func(i int) (*Table[int], error)
+		res   func(i int) ([][]int, error)
+	}{
+		{
+			"Combinations",
+			func(i int) (*Table[int], error) { return CombinationsTable(2, items[:3+i*3]) },
+			func(i int) ([][]int, error) { return Combinations(2, items[:3+i*3]) },
+		},
+		{
+			"Permutations",
+			func(i int) (*Table[int], error) { return PermutationsTable(items[:3+i*3]) },
+			func(i int) ([][]int, error) { return Permutations(items[:3+i*3]) },
+		},
+		{
+			"MultiPermutations",
+			func(i int) (*Table[int], error) {
+				return MultiPermutationsTable(items[:2+i*2], [][]int{{2, 2}, {1, 1, 1, 1}}[i])
+			},
+			func(i int) ([][]int, error) {
+				return MultiPermutations(items[:2+i*2], [][]int{{2, 2}, {1, 1, 1, 1}}[i])
+			},
+		},
+		{
+			"Variations",
+			func(i int) (*Table[int], error) { return VariationsTable(3, items[:2+i*4]) },
+			func(i int) ([][]int, error) { return Variations(3, items[:2+i*4]) },
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			allocs := make([]float64, 0, 2)
+
+			for i := 0; i < 2; i++ {
+				tab, err := c.table(i)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				res, _ := c.res(i)
+
+				if compareSliceOfSlices(tab.Rows(), res) != 0 {
+					t.Errorf("Not equal, \ngot: %v, \nwant: %v", tab.Rows(), res)
+				}
+
+				allocs = append(allocs, testing.AllocsPerRun(10, func() { c.table(i) }))
+			}
+
+			if allocs[0] != allocs[1] {
+				t.Errorf("Number of allocations isn't constant: %v", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkPermutationsTable(b *testing.B) {
+	items := []int{1, 2, 3, 4, 5, 6}
+
+	for k := 2; k <= len(items); k++ {
+		b.Run(fmt.Sprintf("p(%d)=%d", k, PermutationCount(k)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				PermutationsTable(items[0:k])
+			}
+		})
+	}
+}
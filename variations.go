@@ -5,7 +5,10 @@
 package kombinat
 
 import (
+	"encoding/json"
 	"fmt"
+	"iter"
+	"math/big"
 	"slices"
 )
 
@@ -13,61 +16,132 @@ func VariationCount(k, n int) int {
 	return IntPow(n, k)
 }
 
-// Variations with repetitions.
-// Returns an error when k <= 0 or when elems is nil or empty.
-func Variations[T any](k int, elems []T) ([][]T, error) {
-	n := len(elems)
+// VariationCountChecked returns the number of variations of size k out of n elements,
+// or [ErrOverflow] if it doesn't fit in an int.
+func VariationCountChecked(k, n int) (int, error) {
+	return IntPowChecked(n, k)
+}
 
-	if k <= 0 {
-		return nil, fmt.Errorf("k must be bigger than 0")
+// VariationCountBig returns the number of variations of size k out of n elements as [big.Int].
+func VariationCountBig(k, n int) *big.Int {
+	return IntPowBig(n, k)
+}
+
+// VariationRank returns the rank of a variation in the lexicographic order of
+// variations of size len(positions) out of n elements. The variation is given as
+// positions of elements in the input slice, and its rank is the number the positions
+// represent in base n.
+//
+// Returns an error if positions is empty or if a position is not in the range [0, n).
+func VariationRank(n int, positions []int) (int, error) {
+	if len(positions) == 0 {
+		return 0, fmt.Errorf("input slice is nil or empty")
 	}
 
-	if n == 0 {
-		return nil, fmt.Errorf("input slice is nil or empty")
+	rank := 0
+
+	for _, p := range positions {
+		if p < 0 || p >= n {
+			return 0, fmt.Errorf("position out of range")
+		}
+
+		rank = rank*n + p
 	}
 
-	count := VariationCount(k, n)
+	return rank, nil
+}
 
-	res := make([][]T, 0, count)
-	row := 0
-	pows := make([]int, k)
+// VariationUnrank returns positions of the variation at the given rank in the
+// lexicographic order of variations of size k out of n elements. It's the inverse
+// of [VariationRank].
+//
+// Returns an error if k < 1, n < 1, or if the rank is not in the range [0, VariationCount(k, n)).
+func VariationUnrank(k, n, rank int) ([]int, error) {
+	switch {
+	case k <= 0:
+		return nil, fmt.Errorf("k must be >= 1")
+	case n <= 0:
+		return nil, fmt.Errorf("n must be >= 1")
+	case rank < 0 || rank >= VariationCount(k, n):
+		return nil, fmt.Errorf("rank out of range")
+	}
+
+	positions := make([]int, k)
+	variationUnrank(n, rank, positions)
+
+	return positions, nil
+}
 
-	for i := 0; i < k; i++ {
-		pows[i] = IntPow(n, k-i-1)
+// Writes positions of the variation at the lexicographic rank into dest.
+func variationUnrank(n, rank int, dest []int) {
+	for i := len(dest) - 1; i >= 0; i-- {
+		dest[i] = rank % n
+		rank /= n
 	}
+}
 
-	for {
-		if row == count {
-			break
-		}
+// Variations with repetitions.
+// Returns an error when k <= 0 or when elems is nil or empty.
+func Variations[T any](k int, elems []T) ([][]T, error) {
+	return VariationsWithLimit(k, elems)
+}
 
-		ret := make([]T, k)
+// VariationsWithLimit is like [Variations], but returns [TooManyResultsError]
+// before allocating the results if there are more of them than allowed by opts.
+func VariationsWithLimit[T any](k int, elems []T, opts ...LimitOption) ([][]T, error) {
+	t, err := VariationsTable(k, elems, opts...)
 
-		for col := 0; col < k; col++ {
-			i := (row / pows[col]) % n
-			ret[col] = elems[i]
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		res = append(res, ret)
+	return t.Rows(), nil
+}
+
+// VariationsTable is like [VariationsWithLimit], but stores the results in a [Table].
+func VariationsTable[T any](k int, elems []T, opts ...LimitOption) (*Table[T], error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("k must be bigger than 0")
+	}
+
+	gen, err := NewVariationGenerator(k, elems)
+
+	if err != nil {
+		return nil, err
+	}
 
-		row++
+	n := len(elems)
+	count, err := VariationCountChecked(k, n)
+
+	if err := checkLimit[T](count, err, k, opts, func() *big.Int { return VariationCountBig(k, n) }); err != nil {
+		return nil, err
 	}
 
-	return res, nil
+	return fillTable(gen, count, k), nil
 }
 
 // VariationGenerator implements a [Generator] interface
 // for generating variations.
 type VariationGenerator[T any] struct {
-	n, k, count, row int
-	elems, dest      []T
-	pows             []int
+	n, k, row, from, to int
+	elems, dest         []T
+	idx, pows           []int
+	digits, focus, dir  []int
+	opts                []GeneratorOption
+	gray, reverse       bool
+	change              Change
 }
 
 // Init initializes a generator for variations of size k out of elements
 // in the elems slice. Returns an error if input slice is nil or empty,
 // or if k < 1.
-func (gen *VariationGenerator[T]) Init(k int, elems []T) error {
+//
+// Supported options are [Gray], [Lexicographic] and [Reverse], but [Gray] and [Lexicographic]
+// can't be combined. With the [Gray] option, variations are produced in the reflected
+// n-ary Gray code order by the loopless Algorithm H from "The Art of Computer Programming,
+// Volume 4A" by Donald Knuth, where consecutive variations differ in a single element,
+// which is replaced by its neighbour in the input slice.
+func (gen *VariationGenerator[T]) Init(k int, elems []T, opts ...GeneratorOption) error {
 	if k <= 0 {
 		return fmt.Errorf("k must be >= 1")
 	}
@@ -76,30 +150,85 @@ func (gen *VariationGenerator[T]) Init(k int, elems []T) error {
 		return fmt.Errorf("input slice is nil or empty")
 	}
 
-	gen.n = len(elems)
+	return gen.init(k, len(elems), elems, opts)
+}
+
+// Initializes the generator for variations of size k out of n elements. If elems
+// is nil, the generator is in index mode and produces positions instead of elements,
+// which requires T to be int.
+func (gen *VariationGenerator[T]) init(k, n int, elems []T, opts []GeneratorOption) error {
+	o := applyOptions(opts)
+
+	if o.gray && o.lex {
+		return fmt.Errorf("gray code and lexicographic order can't be combined")
+	}
 
 	if k != gen.k {
 		gen.dest = make([]T, k)
 		gen.pows = make([]int, k)
-		gen.count = IntPow(gen.n, k)
+	}
+
+	if k != gen.k || n != gen.n {
+		gen.n = n
 
 		for i := 0; i < k; i++ {
-			gen.pows[i] = IntPow(gen.n, k-i-1)
+			gen.pows[i] = saturate(IntPowChecked(gen.n, k-i-1))
 		}
 	}
 
 	gen.row = 0
+	gen.change = Change{}
 	gen.k = k
 	gen.elems = elems
+	gen.idx = nil
+
+	if elems == nil {
+		gen.idx = any(gen.dest).([]int)
+	}
+
+	gen.opts = opts
+	gen.gray = o.gray
+	gen.reverse = o.reverse
+
+	if gen.gray {
+		gen.digits = make([]int, k)
+		gen.focus = make([]int, k+1)
+		gen.dir = make([]int, k)
+		gen.unrankGray(0)
+	}
+
+	gen.from, gen.to = 0, gen.count()
+
+	if err := checkCount(o, gen.to); err != nil {
+		return err
+	}
+
+	if gen.reverse {
+		gen.end()
+	}
 
 	return nil
 }
 
-// Reset resets the generator to the beginning of the sequence.
+// Returns the number of variations in the entire sequence, or math.MaxInt if it doesn't fit in an int.
+func (gen *VariationGenerator[T]) count() int {
+	return saturate(VariationCountChecked(gen.k, gen.n))
+}
+
+// Reset resets the generator to the beginning of the sequence, or to the beginning
+// of the range set by Range.
+// With the [Reverse] option, it moves the generator to the end instead.
 func (gen *VariationGenerator[T]) Reset() {
-	s := gen.dest
-	gen.Init(gen.k, gen.elems)
+	s, from, to := gen.dest, gen.from, gen.to
+	gen.init(gen.k, gen.n, gen.elems, gen.opts)
 	gen.SetDest(s)
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+	} else if from > 0 {
+		gen.Seek(from)
+	}
 }
 
 // Current returns the internal slice that holds the current variation.
@@ -127,31 +256,420 @@ func (gen *VariationGenerator[T]) SetDest(dest []T) error {
 	copy(dest, gen.dest)
 	gen.dest = dest
 
+	if gen.idx != nil {
+		gen.idx = any(dest).([]int)
+	}
+
 	return nil
 }
 
 // Next produces a new variation in the generator. If it returns false,
 // there are no more variations available.
 func (gen *VariationGenerator[T]) Next() bool {
-	if gen.k <= 0 || gen.row == gen.count {
+	if gen.k <= 0 || gen.row >= gen.to {
 		return false
 	}
 
-	for col := 0; col < gen.k; col++ {
-		i := (gen.row / gen.pows[col]) % gen.n
-		gen.dest[col] = gen.elems[i]
+	if gen.gray {
+		return gen.nextGray()
+	}
+
+	gen.fill(gen.row)
+
+	if gen.change.Kind == ChangeNone {
+		gen.change = Change{Kind: ChangeAll}
+	} else {
+		last := gen.row % gen.n
+		gen.change = rangeChange(gen.changed(gen.row), gen.k, (last+gen.n-1)%gen.n, last)
+	}
+
+	gen.row++
+
+	return true
+}
+
+// Moves the generator to the next variation in the Gray code order. Digits of the code
+// are the positions of elements from the last column, which is the least significant one.
+func (gen *VariationGenerator[T]) nextGray() bool {
+	if gen.change.Kind == ChangeNone {
+		for j, d := range gen.digits {
+			gen.set(gen.k-j-1, d)
+		}
+
+		gen.change = Change{Kind: ChangeAll}
+		gen.row++
+
+		return true
+	}
+
+	j := gen.focus[0]
+
+	if j == gen.k {
+		return false
+	}
+
+	gen.focus[0] = 0
+	old := gen.digits[j]
+	d := old + gen.dir[j]
+	gen.digits[j] = d
+
+	// the digit is passive at the end of its direction, until the next digit changes
+	if d == 0 || d == gen.n-1 {
+		gen.dir[j] = -gen.dir[j]
+		gen.focus[j] = gen.focus[j+1]
+		gen.focus[j+1] = j + 1
 	}
 
+	col := gen.k - j - 1
+	gen.set(col, d)
+	gen.change = Change{Kind: ChangeReplace, I: col, Old: old, New: d}
 	gen.row++
 
 	return true
 }
 
+// Prev produces the variation before the current one in the sequence of the generator.
+// If it returns false, there are no previous variations available in the range.
+// With the [Gray] option, it seeks to the previous variation.
+func (gen *VariationGenerator[T]) Prev() bool {
+	if gen.gray {
+		return gen.k > 0 && prevBySeek[T](gen, gen.from, gen.to)
+	}
+
+	// right after Seek, the previous result is the one before the sought rank
+	rank := gen.row - 2
+
+	if gen.change.Kind == ChangeNone {
+		rank = min(gen.row, gen.to) - 1
+	}
+
+	if gen.k <= 0 || rank < gen.from {
+		return false
+	}
+
+	gen.row = rank + 1
+	gen.fill(rank)
+
+	if gen.change.Kind == ChangeNone {
+		gen.change = Change{Kind: ChangeAll}
+	} else {
+		last := gen.row % gen.n
+		gen.change = rangeChange(gen.changed(gen.row), gen.k, last, (last+gen.n-1)%gen.n)
+	}
+
+	return true
+}
+
+// Writes the variation at the rank into dest.
+func (gen *VariationGenerator[T]) fill(rank int) {
+	if gen.idx != nil {
+		for col := 0; col < gen.k; col++ {
+			gen.idx[col] = (rank / gen.pows[col]) % gen.n
+		}
+	} else {
+		for col := 0; col < gen.k; col++ {
+			i := (rank / gen.pows[col]) % gen.n
+			gen.dest[col] = gen.elems[i]
+		}
+	}
+}
+
+// Writes the element at position i into the column col.
+func (gen *VariationGenerator[T]) set(col, i int) {
+	if gen.idx != nil {
+		gen.idx[col] = i
+	} else {
+		gen.dest[col] = gen.elems[i]
+	}
+}
+
+// Sets the state of the Gray code to the variation at the rank. A digit of the code is
+// the digit of the rank in base n, reflected when the number formed by the more significant
+// digits is odd. Focus pointers of runs of passive digits, which are n-1 in the rank,
+// point past the run, while the others point to their own digit.
+func (gen *VariationGenerator[T]) unrankGray(rank int) {
+	start := -1
+
+	for j := range gen.k {
+		b := rank % gen.n
+		rank /= gen.n
+
+		d, dir := b, 1
+
+		if rank%2 == 1 {
+			d, dir = gen.n-b-1, -1
+		}
+
+		// directions are reversed as soon as a digit reaches its end
+		switch d {
+		case 0:
+			dir = 1
+		case gen.n - 1:
+			dir = -1
+		}
+
+		gen.digits[j], gen.dir[j], gen.focus[j] = d, dir, j
+
+		if b == gen.n-1 {
+			if start < 0 {
+				start = j
+			}
+		} else if start >= 0 {
+			gen.focus[start] = j
+			start = -1
+		}
+	}
+
+	gen.focus[gen.k] = gen.k
+
+	if start >= 0 {
+		gen.focus[start] = gen.k
+	}
+}
+
+// Returns the first column that differs between variations at the rank and the one before it.
+func (gen *VariationGenerator[T]) changed(rank int) int {
+	// digits after the first changed one in base n wrap from n-1 to 0
+	col := gen.k - 1
+
+	for r := rank; r%gen.n == 0; r /= gen.n {
+		col--
+	}
+
+	return col
+}
+
+// LastChange returns the change of the current variation made by the last call to
+// [VariationGenerator.Next]. Every variation after the first
+// one differs from the previous one in the last element ([ChangeReplace]),
+// or in elements from some position to the end ([ChangeRange]).
+// With the [Gray] option, it always differs in a single element ([ChangeReplace]),
+// whose position in the input changes by one.
+func (gen *VariationGenerator[T]) LastChange() Change {
+	return gen.change
+}
+
+// Position returns the rank of the current variation in the sequence of the generator,
+// or -1 if [VariationGenerator.Next] hasn't been called yet.
+// With the [Reverse] option, it's the end of the range until [VariationGenerator.Prev] is called.
+func (gen *VariationGenerator[T]) Position() int {
+	return gen.row - 1
+}
+
+// Seek moves the generator to the given rank in its own sequence without iterating,
+// so that the following call to [VariationGenerator.Next] produces the variation
+// at that rank, and [VariationGenerator.Prev]
+// the one before it. Seeking to the end of the range set by [VariationGenerator.Range]
+// (VariationCount(k, n) by default) moves the generator to the end.
+// Returns an error if the rank is out of range.
+func (gen *VariationGenerator[T]) Seek(rank int) error {
+	if rank < gen.from || rank > gen.to {
+		return fmt.Errorf("rank out of range")
+	}
+
+	gen.change = Change{}
+
+	gen.row = rank
+
+	if gen.gray {
+		gen.unrankGray(min(rank, gen.count()-1))
+	}
+
+	return nil
+}
+
+// Range limits the generator to the variations with ranks in the range [from, to)
+// and moves it to the rank from. [VariationGenerator.Reset] preserves the range, and
+// [VariationGenerator.Seek] is allowed only within it. Returns an error if the range is
+// invalid, i.e. if it's not within [0, VariationCount(k, n)].
+func (gen *VariationGenerator[T]) Range(from, to int) error {
+	if err := checkRange(from, to, gen.count()); err != nil {
+		return err
+	}
+
+	gen.from, gen.to = from, to
+
+	if gen.reverse {
+		gen.end()
+		return nil
+	}
+
+	return gen.Seek(from)
+}
+
+// Moves the generator past the end of its range, where only Prev can be called.
+func (gen *VariationGenerator[T]) end() {
+	gen.row = gen.to + 1
+	gen.change = Change{}
+}
+
+// Shard limits the generator to the i-th out of total contiguous and non-overlapping
+// parts of its sequence, so that each part can be processed by a different generator.
+// Sizes of the parts differ by one at most. Returns an error if i is not in the range
+// [0, total).
+func (gen *VariationGenerator[T]) Shard(i, total int) error {
+	from, to, err := shardRange(i, total, gen.count())
+
+	if err != nil {
+		return err
+	}
+
+	return gen.Range(from, to)
+}
+
+// All returns an iterator over all variations paired with their rank, starting from
+// the beginning of the sequence, or backwards from its end with the [Reverse] option.
+// The yielded slice is the one returned by
+// [VariationGenerator.Current]. If the loop is stopped early, the generator stays on the
+// last yielded variation and can be resumed with [VariationGenerator.Next] or restarted with
+// [VariationGenerator.Reset].
+func (gen *VariationGenerator[T]) All() iter.Seq2[int, []T] {
+	return all[T](gen, gen.reverse)
+}
+
+// Values is like [VariationGenerator.All], but it yields only the variations.
+func (gen *VariationGenerator[T]) Values() iter.Seq[[]T] {
+	return values[T](gen, gen.reverse)
+}
+
+// NextBatch fills dst with up to len(dst) following variations, as if by calling
+// [VariationGenerator.Next] and [VariationGenerator.CurrentCopy] for each one, but reusing memory of the
+// slices in dst when there's enough capacity. Returns the number of variations written,
+// which is less than len(dst) only at the end of the sequence.
+func (gen *VariationGenerator[T]) NextBatch(dst [][]T) int {
+	return nextBatch[T](gen, dst)
+}
+
+// NextBatchFlat is like [VariationGenerator.NextBatch], but writes the variations one after another
+// into dst, where every variation takes k elements. Writes as many variations as fit into dst.
+func (gen *VariationGenerator[T]) NextBatchFlat(dst []T) int {
+	return nextBatchFlat[T](gen, dst, gen.k)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler]. It encodes the state of the algorithm,
+// the position of the generator, its range and options, and the size k and the number of
+// elements, but not the elements, so the state can be restored only into a generator
+// initialized with the same input and options.
+func (gen *VariationGenerator[T]) MarshalBinary() ([]byte, error) {
+	return gen.state().MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]. It restores the state encoded by
+// [VariationGenerator.MarshalBinary] into the generator, which must be initialized with the
+// same input and options, so that [VariationGenerator.Current] returns the same variation, and the following
+// call to [VariationGenerator.Next] continues the sequence. Returns an error if the state is
+// invalid or if it doesn't match the generator.
+func (gen *VariationGenerator[T]) UnmarshalBinary(data []byte) error {
+	st := new(generatorState)
+
+	if err := st.UnmarshalBinary(data); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// MarshalJSON implements [json.Marshaler]. It's the JSON equivalent of [VariationGenerator.MarshalBinary].
+func (gen *VariationGenerator[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gen.state())
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]. It's the JSON equivalent of [VariationGenerator.UnmarshalBinary].
+func (gen *VariationGenerator[T]) UnmarshalJSON(data []byte) error {
+	st := new(generatorState)
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return err
+	}
+
+	return gen.load(st)
+}
+
+// Returns the checkpoint of the generator. Its variable is the row, and with the [Gray]
+// option, its slices are the digits, the focus pointers and the directions of the Gray code.
+func (gen *VariationGenerator[T]) state() *generatorState {
+	st := newState(gen.opts, gen.Position(), gen.from, gen.to, gen.change, gen.k, gen.n)
+	st.Vars = []int{gen.row}
+
+	if gen.gray {
+		st.Slices = [][]int{slices.Clone(gen.digits), slices.Clone(gen.focus), slices.Clone(gen.dir)}
+	}
+
+	return st
+}
+
+// Restores the checkpoint made by state, and writes the current variation into dest.
+func (gen *VariationGenerator[T]) load(st *generatorState) error {
+	var lens []int
+
+	if gen.gray {
+		lens = []int{gen.k, gen.k + 1, gen.k}
+	}
+
+	if err := st.check(gen.opts, gen.count(), []int{gen.k, gen.n}, 1, lens...); err != nil {
+		return err
+	}
+
+	if st.Vars[0] != st.Pos+1 {
+		return fmt.Errorf("invalid state data")
+	}
+
+	if gen.gray {
+		digits, focus, dir := st.Slices[0], st.Slices[1], st.Slices[2]
+
+		if !inRange(digits, 0, gen.n-1) || !inRange(focus, 0, gen.k) || !inRange(dir, -1, 1) ||
+			slices.Contains(dir, 0) {
+			return fmt.Errorf("invalid state data")
+		}
+
+		copy(gen.digits, digits)
+		copy(gen.focus, focus)
+		copy(gen.dir, dir)
+	}
+
+	gen.row = st.Vars[0]
+	gen.from, gen.to = st.From, st.To
+	gen.change = st.Change
+
+	// between results, e.g. right after Seek, the Gray code holds the next variation
+	if gen.change.Kind == ChangeNone || gen.row == 0 {
+		return nil
+	}
+
+	if gen.gray {
+		for j, d := range gen.digits {
+			gen.set(gen.k-j-1, d)
+		}
+	} else {
+		gen.fill(gen.row - 1)
+	}
+
+	return nil
+}
+
+// Clone returns an independent copy of the generator in the same state, e.g. for
+// looking ahead without moving the original generator. The copy has its own destination
+// slice that holds the current variation, and shares only the input with the original.
+func (gen *VariationGenerator[T]) Clone() *VariationGenerator[T] {
+	c := *gen
+	c.dest = slices.Clone(gen.dest)
+	c.pows = slices.Clone(gen.pows)
+	c.digits = slices.Clone(gen.digits)
+	c.focus = slices.Clone(gen.focus)
+	c.dir = slices.Clone(gen.dir)
+
+	if gen.idx != nil {
+		c.idx = any(c.dest).([]int)
+	}
+
+	return &c
+}
+
 // NewVariationGenerator creates and initializes a new VariationGenerator.
 // Arguments and returned errors are the same ones from the [VariationGenerator.Init] method.
-func NewVariationGenerator[T any](k int, elems []T) (*VariationGenerator[T], error) {
+func NewVariationGenerator[T any](k int, elems []T, opts ...GeneratorOption) (*VariationGenerator[T], error) {
 	gen := new(VariationGenerator[T])
-	err := gen.Init(k, elems)
+	err := gen.Init(k, elems, opts...)
 
 	if err != nil {
 		return nil, err
@@ -159,3 +677,41 @@ func NewVariationGenerator[T any](k int, elems []T) (*VariationGenerator[T], err
 
 	return gen, nil
 }
+
+// NewVariationIndexGenerator creates a new VariationGenerator that produces variations
+// of size k as positions of elements in an implicit input of size n, i.e. as integers
+// in the range [0, n), without requiring the input slice. Returns an error if k < 1 or n < 1,
+// or if the options can't be applied, see [VariationGenerator.Init].
+func NewVariationIndexGenerator(k, n int, opts ...GeneratorOption) (*VariationGenerator[int], error) {
+	switch {
+	case k <= 0:
+		return nil, fmt.Errorf("k must be >= 1")
+	case n <= 0:
+		return nil, fmt.Errorf("n must be >= 1")
+	}
+
+	gen := new(VariationGenerator[int])
+
+	if err := gen.init(k, n, nil, opts); err != nil {
+		return nil, err
+	}
+
+	return gen, nil
+}
+
+// VariationsSeq returns an iterator over variations of size k out of elements in
+// the elems slice, paired with their rank. The sequence is empty if the arguments
+// are invalid, see [VariationGenerator.Init] for details.
+//
+// The yielded slice is reused between iterations, clone it if you need to keep it.
+func VariationsSeq[T any](k int, elems []T) iter.Seq2[int, []T] {
+	return func(yield func(int, []T) bool) {
+		gen, err := NewVariationGenerator(k, elems)
+
+		if err != nil {
+			return
+		}
+
+		gen.All()(yield)
+	}
+}
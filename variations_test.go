@@ -5,6 +5,7 @@
 package kombinat
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"testing"
@@ -144,6 +145,61 @@ func TestVariations(t *testing.T) {
 	}
 }
 
+func TestVariationCount(t *testing.T) {
+	if n := VariationCount(3, 4); n != 64 {
+		t.Errorf("Want 64, got %v", n)
+	}
+	if _, err := VariationCountChecked(64, 2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Want ErrOverflow, got %v", err)
+	}
+	if n := VariationCountBig(64, 2).String(); n != "18446744073709551616" {
+		t.Errorf("Want 18446744073709551616, got %v", n)
+	}
+}
+
+func TestVariationRank(t *testing.T) {
+	for _, vd := range _var_data {
+		vd := vd
+		n := len(vd.input)
+
+		t.Run(fmt.Sprintf("v(%d,%d)", vd.k, n), func(t *testing.T) {
+			for r, w := range vd.want {
+				positions, err := VariationUnrank(vd.k, n, r)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				for i, p := range positions {
+					if vd.input[p] != w[i] {
+						t.Errorf("Not equal at %v, \ngot: %v, \nwant: %v", r, positions, w)
+						break
+					}
+				}
+
+				if rank, err := VariationRank(n, positions); err != nil || rank != r {
+					t.Errorf("Wrong rank for %v, got: %v (%v), want: %v", positions, rank, err, r)
+				}
+			}
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := VariationUnrank(2, 3, 9); err == nil {
+			t.Errorf("Didn't err on rank out of range")
+		}
+		if _, err := VariationUnrank(0, 3, 0); err == nil {
+			t.Errorf("Didn't err on k = 0")
+		}
+		if _, err := VariationRank(3, []int{0, 3}); err == nil {
+			t.Errorf("Didn't err on position out of range")
+		}
+		if _, err := VariationRank(3, nil); err == nil {
+			t.Errorf("Didn't err on empty input")
+		}
+	})
+}
+
 func TestVariationGenerator(t *testing.T) {
 	for _, vd := range _var_data {
 		vd := vd
@@ -191,6 +247,228 @@ func TestVariationGenerator(t *testing.T) {
 	}
 }
 
+func TestVariationGeneratorAll(t *testing.T) {
+	for _, vd := range _var_data {
+		vd := vd
+
+		t.Run(fmt.Sprintf("v(%d,%d)", vd.k, len(vd.input)), func(t *testing.T) {
+			gen, _ := NewVariationGenerator(vd.k, vd.input)
+			res := make([][]int, 0, len(vd.want))
+
+			for i, v := range gen.All() {
+				if i != len(res) {
+					t.Errorf("Wrong rank, got: %v, want: %v", i, len(res))
+				}
+				res = append(res, slices.Clone(v))
+			}
+
+			if compareSliceOfSlices(res, vd.want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, vd.want)
+			}
+
+			res = res[:0]
+
+			for v := range gen.Values() {
+				res = append(res, slices.Clone(v))
+			}
+
+			if compareSliceOfSlices(res, vd.want) != 0 {
+				t.Errorf("Not equal (values), \ngot: %v, \nwant: %v", res, vd.want)
+			}
+
+			// break + reset
+			for range gen.All() {
+				break
+			}
+
+			if gen.Next() && len(vd.want) > 1 && slices.Compare(gen.Current(), vd.want[1]) != 0 {
+				t.Errorf("Not resumed after break, got: %v, want: %v", gen.Current(), vd.want[1])
+			}
+
+			gen.Reset()
+
+			if gen.Next(); slices.Compare(gen.Current(), vd.want[0]) != 0 {
+				t.Errorf("Not reset after break, got: %v, want: %v", gen.Current(), vd.want[0])
+			}
+		})
+	}
+}
+
+func TestVariationsSeq(t *testing.T) {
+	for _, vd := range _var_data {
+		vd := vd
+
+		t.Run(fmt.Sprintf("v(%d,%d)", vd.k, len(vd.input)), func(t *testing.T) {
+			res := make([][]int, 0, len(vd.want))
+
+			for i, v := range VariationsSeq(vd.k, vd.input) {
+				if i != len(res) {
+					t.Errorf("Wrong rank, got: %v, want: %v", i, len(res))
+				}
+				res = append(res, slices.Clone(v))
+			}
+
+			if compareSliceOfSlices(res, vd.want) != 0 {
+				t.Errorf("Not equal, \ngot: %v, \nwant: %v", res, vd.want)
+			}
+		})
+	}
+
+	for range VariationsSeq(0, []int{1, 2}) {
+		t.Errorf("Didn't return an empty sequence on invalid input")
+	}
+}
+
+func TestVariationGeneratorSeek(t *testing.T) {
+	for _, vd := range _var_data {
+		vd := vd
+
+		t.Run(fmt.Sprintf("v(%d,%d)", vd.k, len(vd.input)), func(t *testing.T) {
+			gen, _ := NewVariationGenerator(vd.k, vd.input)
+
+			for r := range vd.want {
+				if err := gen.Seek(r); err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				if gen.Position() != r-1 {
+					t.Errorf("Wrong position after seek, got: %v, want: %v", gen.Position(), r-1)
+				}
+
+				for i := r; i < len(vd.want); i++ {
+					if !gen.Next() || slices.Compare(gen.Current(), vd.want[i]) != 0 {
+						t.Fatalf("Not equal at %v after seek to %v, \ngot: %v, \nwant: %v", i, r, gen.Current(), vd.want[i])
+					}
+				}
+
+				if gen.Next() {
+					t.Errorf("Didn't return false on end after seek to %v", r)
+				}
+			}
+
+			if err := gen.Seek(len(vd.want)); err != nil || gen.Next() {
+				t.Errorf("Didn't seek to end")
+			}
+			if gen.Seek(len(vd.want)+1) == nil || gen.Seek(-1) == nil {
+				t.Errorf("Didn't err on rank out of range")
+			}
+		})
+	}
+}
+
+func TestVariationGeneratorShard(t *testing.T) {
+	for _, vd := range _var_data {
+		gen, _ := NewVariationGenerator(vd.k, vd.input)
+		testShards[int](t, gen)
+	}
+}
+
+func TestVariationIndexGenerator(t *testing.T) {
+	for n := 1; n <= 4; n++ {
+		for k := 1; k <= 3; k++ {
+			t.Run(fmt.Sprintf("v(%d,%d)", k, n), func(t *testing.T) {
+				gen, err := NewVariationIndexGenerator(k, n)
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				want, _ := Variations(k, indices(n))
+				testGenerator[int](t, gen, want)
+			})
+		}
+	}
+
+	gen, _ := NewVariationIndexGenerator(2, 3)
+
+	if err := gen.Init(2, []int{5, 6, 7}); err != nil {
+		t.Fatalf("Error'd with: %v", err)
+	}
+
+	if gen.Next(); slices.Compare(gen.Current(), []int{5, 5}) != 0 {
+		t.Errorf("Not equal after init with elements, \ngot: %v, \nwant: %v", gen.Current(), []int{5, 5})
+	}
+
+	if _, err := NewVariationIndexGenerator(0, 3); err == nil {
+		t.Errorf("Didn't err on k = 0")
+	}
+	if _, err := NewVariationIndexGenerator(2, 0); err == nil {
+		t.Errorf("Didn't err on n = 0")
+	}
+}
+
+// Returns variations of size k out of elems in the reflected Gray code order, by appending
+// elements to every variation of size k-1, alternately forwards and backwards.
+func reflectedVariations[T any](k int, elems []T) [][]T {
+	if k == 0 {
+		return [][]T{{}}
+	}
+
+	res := make([][]T, 0, VariationCount(k, len(elems)))
+
+	for r, v := range reflectedVariations(k-1, elems) {
+		for i := range elems {
+			if r%2 == 1 {
+				i = len(elems) - i - 1
+			}
+			res = append(res, append(slices.Clone(v), elems[i]))
+		}
+	}
+
+	return res
+}
+
+func TestVariationGeneratorGray(t *testing.T) {
+	want := [][]int{{0, 0}, {0, 1}, {0, 2}, {1, 2}, {1, 1}, {1, 0}, {2, 0}, {2, 1}, {2, 2}}
+
+	if res := reflectedVariations(2, indices(3)); compareSliceOfSlices(res, want) != 0 {
+		t.Fatalf("Unexpected reference, \ngot: %v, \nwant: %v", res, want)
+	}
+
+	elems := []string{"A", "B", "C", "D"}
+
+	for n := 1; n <= len(elems); n++ {
+		for k := 1; k <= 4; k++ {
+			t.Run(fmt.Sprintf("v(%d,%d)", k, n), func(t *testing.T) {
+				items := elems[:n]
+				want := reflectedVariations(k, items)
+				gen, err := NewVariationGenerator(k, items, Gray())
+
+				if err != nil {
+					t.Fatalf("Error'd with: %v", err)
+				}
+
+				testGenerator[string](t, gen, want)
+
+				if len(want) > 1 {
+					gen.Reset()
+					testChanges[string](t, gen, items)
+				}
+
+				gen.Range(0, len(want))
+
+				for gen.Next() {
+					if c := gen.LastChange(); gen.Position() > 0 && (c.Kind != ChangeReplace || c.Old-c.New != 1 && c.New-c.Old != 1) {
+						t.Fatalf("Not a change by one at %v: %v", gen.Position(), c)
+					}
+				}
+
+				testPrev(t, items, func(opts ...GeneratorOption) reversibleGenerator[string] {
+					gen, _ := NewVariationGenerator(k, items, append(opts, Gray())...)
+					return gen
+				})
+
+				igen, _ := NewVariationIndexGenerator(k, n, Gray())
+				testClone[int](t, igen)
+			})
+		}
+	}
+
+	if _, err := NewVariationGenerator(2, elems, Gray(), Lexicographic()); err == nil {
+		t.Errorf("Didn't err on gray code and lexicographic order")
+	}
+}
+
 func BenchmarkVariations(b *testing.B) {
 	items := []int{1, 2, 3, 4}
 
@@ -222,3 +500,21 @@ func BenchmarkVariationGenerator(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkVariationGeneratorGray(b *testing.B) {
+	items := []int{1, 2, 3, 4}
+
+	for k := 2; k <= 6; k++ {
+		k := k
+		gen := new(VariationGenerator[int])
+
+		b.Run(fmt.Sprintf("v(%d,4)=%d", k, VariationCount(k, 4)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				gen.Init(k, items, Gray())
+				for gen.Next() {
+					gen.Current()
+				}
+			}
+		})
+	}
+}